@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
@@ -44,9 +47,18 @@ func styledHelp(cmd *cobra.Command, args []string) {
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs             "), descStyle.Render("Browse packs in TUI"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs find <query>"), descStyle.Render("Search for packs"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs get <name>  "), descStyle.Render("Install a pack"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs install     "), descStyle.Render("Install packs from packs.yaml"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs list        "), descStyle.Render("List installed packs"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs outdated    "), descStyle.Render("Show packs with newer versions"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs update      "), descStyle.Render("Update installed packs"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs diff <pk>   "), descStyle.Render("Show local edits to a pack"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs remove <pk> "), descStyle.Render("Uninstall a pack"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs info <name> "), descStyle.Render("Show pack details"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs submit <ref>"), descStyle.Render("Submit a pack to registry"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs sign [dir]  "), descStyle.Render("Sign a pack for publishing"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs config      "), descStyle.Render("Show or set configuration"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs cache       "), descStyle.Render("Inspect and manage the local cache"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("packs gc          "), descStyle.Render("Delete unused store entries"))
 	fmt.Println()
 	
 	fmt.Println(titleStyle.Render("  GITHUB FETCH"))
@@ -62,6 +74,8 @@ func styledHelp(cmd *cobra.Command, args []string) {
 	fmt.Println(titleStyle.Render("  OPTIONS"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("-j, --json    "), descStyle.Render("Output as JSON"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("    --no-cache"), descStyle.Render("Bypass local cache"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("    --offline "), descStyle.Render("Use cached data only"))
+	fmt.Printf("    %s  %s\n", cmdStyle.Render("-r, --registry"), descStyle.Render("Override registry URL"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("-h, --help    "), descStyle.Render("Show this help"))
 	fmt.Printf("    %s  %s\n", cmdStyle.Render("-v, --version "), descStyle.Render("Show version"))
 	fmt.Println()
@@ -76,7 +90,10 @@ func main() {
 		Long:  banner + "\n  packs is a TUI-first tool for discovering, installing,\n  and sharing AI skills, prompts, and context.",
 		Run: func(cmd *cobra.Command, args []string) {
 			// No args = launch TUI
-			commands.RunTUI()
+			commands.RunTUI(cmd.Context())
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return commands.ApplyGlobalFlags(cmd)
 		},
 	}
 	
@@ -91,10 +108,19 @@ func main() {
 
 	// Add commands
 	rootCmd.AddCommand(commands.GetCmd())
+	rootCmd.AddCommand(commands.InstallCmd())
+	rootCmd.AddCommand(commands.ListCmd())
+	rootCmd.AddCommand(commands.OutdatedCmd())
+	rootCmd.AddCommand(commands.UpdateCmd())
+	rootCmd.AddCommand(commands.DiffCmd())
+	rootCmd.AddCommand(commands.RemoveCmd())
 	rootCmd.AddCommand(commands.FindCmd())
 	rootCmd.AddCommand(commands.InfoCmd())
 	rootCmd.AddCommand(commands.SubmitCmd())
+	rootCmd.AddCommand(commands.SignCmd())
 	rootCmd.AddCommand(commands.ConfigCmd())
+	rootCmd.AddCommand(commands.CacheCmd())
+	rootCmd.AddCommand(commands.GCCmd())
 	rootCmd.AddCommand(commands.LoginCmd())
 	rootCmd.AddCommand(commands.LogoutCmd())
 	rootCmd.AddCommand(commands.WhoamiCmd())
@@ -102,9 +128,25 @@ func main() {
 	// Global flags
 	rootCmd.PersistentFlags().BoolP("json", "j", false, "Output as JSON")
 	rootCmd.PersistentFlags().Bool("no-cache", false, "Bypass local cache")
+	rootCmd.PersistentFlags().Bool("offline", false, "Use cached data only")
+	rootCmd.PersistentFlags().StringP("registry", "r", "", "Override registry URL")
+
+	// The first Ctrl-C cancels the command so installs can roll back; a
+	// second one kills it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.ExecuteContext(ctx)
+	interrupted := ctx.Err() != nil
+	stop()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		if interrupted {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 }
@@ -90,7 +90,12 @@ type Pack struct {
 	GithubRef     string                 `protobuf:"bytes,11,opt,name=github_ref,json=githubRef,proto3" json:"github_ref,omitempty"` // e.g., "tunajam/packs-registry/skills/commit-message"
 	CreatedAt     int64                  `protobuf:"varint,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     int64                  `protobuf:"varint,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	SourceUrl     string                 `protobuf:"bytes,14,opt,name=source_url,json=sourceUrl,proto3" json:"source_url,omitempty"` // Original source attribution URL
+	SourceUrl     string                 `protobuf:"bytes,14,opt,name=source_url,json=sourceUrl,proto3" json:"source_url,omitempty"`             // Original source attribution URL
+	Signature     string                 `protobuf:"bytes,15,opt,name=signature,proto3" json:"signature,omitempty"`                              // Base64 ed25519 signature, see internal/signing
+	KeyId         string                 `protobuf:"bytes,16,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`                         // ID of the key that made the signature
+	Files         []*PackFile            `protobuf:"bytes,17,rep,name=files,proto3" json:"files,omitempty"`                                      // Every file of the pack; content is the main file
+	ArchiveUrl    string                 `protobuf:"bytes,18,opt,name=archive_url,json=archiveUrl,proto3" json:"archive_url,omitempty"`          // tar.gz or zip of the pack; files then carry only hashes
+	ArchiveSha256 string                 `protobuf:"bytes,19,opt,name=archive_sha256,json=archiveSha256,proto3" json:"archive_sha256,omitempty"` // Hex SHA-256 of the archive
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -223,6 +228,118 @@ func (x *Pack) GetSourceUrl() string {
 	return ""
 }
 
+func (x *Pack) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *Pack) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
+func (x *Pack) GetFiles() []*PackFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *Pack) GetArchiveUrl() string {
+	if x != nil {
+		return x.ArchiveUrl
+	}
+	return ""
+}
+
+func (x *Pack) GetArchiveSha256() string {
+	if x != nil {
+		return x.ArchiveSha256
+	}
+	return ""
+}
+
+// PackFile is one file of a pack directory
+type PackFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`  // Slash-separated, relative to the pack root
+	Mode          uint32                 `protobuf:"varint,2,opt,name=mode,proto3" json:"mode,omitempty"` // Permission bits: 0644, or 0755 for scripts
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256        string                 `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`   // Hex SHA-256 of content
+	Content       []byte                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"` // Empty when the pack is delivered as an archive
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PackFile) Reset() {
+	*x = PackFile{}
+	mi := &file_packs_v1_packs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PackFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackFile) ProtoMessage() {}
+
+func (x *PackFile) ProtoReflect() protoreflect.Message {
+	mi := &file_packs_v1_packs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackFile.ProtoReflect.Descriptor instead.
+func (*PackFile) Descriptor() ([]byte, []int) {
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PackFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *PackFile) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *PackFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *PackFile) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *PackFile) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
 // PackSummary is a lightweight pack for listings
 type PackSummary struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -241,7 +358,7 @@ type PackSummary struct {
 
 func (x *PackSummary) Reset() {
 	*x = PackSummary{}
-	mi := &file_packs_v1_packs_proto_msgTypes[1]
+	mi := &file_packs_v1_packs_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -253,7 +370,7 @@ func (x *PackSummary) String() string {
 func (*PackSummary) ProtoMessage() {}
 
 func (x *PackSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[1]
+	mi := &file_packs_v1_packs_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -266,7 +383,7 @@ func (x *PackSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PackSummary.ProtoReflect.Descriptor instead.
 func (*PackSummary) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{1}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *PackSummary) GetName() string {
@@ -348,7 +465,7 @@ type SearchRequest struct {
 
 func (x *SearchRequest) Reset() {
 	*x = SearchRequest{}
-	mi := &file_packs_v1_packs_proto_msgTypes[2]
+	mi := &file_packs_v1_packs_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -360,7 +477,7 @@ func (x *SearchRequest) String() string {
 func (*SearchRequest) ProtoMessage() {}
 
 func (x *SearchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[2]
+	mi := &file_packs_v1_packs_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -373,7 +490,7 @@ func (x *SearchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
 func (*SearchRequest) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{2}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *SearchRequest) GetQuery() string {
@@ -435,7 +552,7 @@ type SearchResponse struct {
 
 func (x *SearchResponse) Reset() {
 	*x = SearchResponse{}
-	mi := &file_packs_v1_packs_proto_msgTypes[3]
+	mi := &file_packs_v1_packs_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -447,7 +564,7 @@ func (x *SearchResponse) String() string {
 func (*SearchResponse) ProtoMessage() {}
 
 func (x *SearchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[3]
+	mi := &file_packs_v1_packs_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -460,7 +577,7 @@ func (x *SearchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
 func (*SearchResponse) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{3}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SearchResponse) GetPacks() []*PackSummary {
@@ -488,7 +605,7 @@ type GetRequest struct {
 
 func (x *GetRequest) Reset() {
 	*x = GetRequest{}
-	mi := &file_packs_v1_packs_proto_msgTypes[4]
+	mi := &file_packs_v1_packs_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -500,7 +617,7 @@ func (x *GetRequest) String() string {
 func (*GetRequest) ProtoMessage() {}
 
 func (x *GetRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[4]
+	mi := &file_packs_v1_packs_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -513,7 +630,7 @@ func (x *GetRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
 func (*GetRequest) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{4}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetRequest) GetName() string {
@@ -539,7 +656,7 @@ type GetResponse struct {
 
 func (x *GetResponse) Reset() {
 	*x = GetResponse{}
-	mi := &file_packs_v1_packs_proto_msgTypes[5]
+	mi := &file_packs_v1_packs_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -551,7 +668,7 @@ func (x *GetResponse) String() string {
 func (*GetResponse) ProtoMessage() {}
 
 func (x *GetResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[5]
+	mi := &file_packs_v1_packs_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -564,7 +681,7 @@ func (x *GetResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
 func (*GetResponse) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{5}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetResponse) GetPack() *Pack {
@@ -584,7 +701,7 @@ type SubmitRequest struct {
 
 func (x *SubmitRequest) Reset() {
 	*x = SubmitRequest{}
-	mi := &file_packs_v1_packs_proto_msgTypes[6]
+	mi := &file_packs_v1_packs_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -596,7 +713,7 @@ func (x *SubmitRequest) String() string {
 func (*SubmitRequest) ProtoMessage() {}
 
 func (x *SubmitRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[6]
+	mi := &file_packs_v1_packs_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -609,7 +726,7 @@ func (x *SubmitRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubmitRequest.ProtoReflect.Descriptor instead.
 func (*SubmitRequest) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{6}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *SubmitRequest) GetGithubRef() string {
@@ -631,7 +748,7 @@ type SubmitResponse struct {
 
 func (x *SubmitResponse) Reset() {
 	*x = SubmitResponse{}
-	mi := &file_packs_v1_packs_proto_msgTypes[7]
+	mi := &file_packs_v1_packs_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -643,7 +760,7 @@ func (x *SubmitResponse) String() string {
 func (*SubmitResponse) ProtoMessage() {}
 
 func (x *SubmitResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[7]
+	mi := &file_packs_v1_packs_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -656,7 +773,7 @@ func (x *SubmitResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubmitResponse.ProtoReflect.Descriptor instead.
 func (*SubmitResponse) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{7}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *SubmitResponse) GetSuccess() bool {
@@ -702,7 +819,7 @@ type TelemetryEvent struct {
 
 func (x *TelemetryEvent) Reset() {
 	*x = TelemetryEvent{}
-	mi := &file_packs_v1_packs_proto_msgTypes[8]
+	mi := &file_packs_v1_packs_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -714,7 +831,7 @@ func (x *TelemetryEvent) String() string {
 func (*TelemetryEvent) ProtoMessage() {}
 
 func (x *TelemetryEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[8]
+	mi := &file_packs_v1_packs_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -727,7 +844,7 @@ func (x *TelemetryEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TelemetryEvent.ProtoReflect.Descriptor instead.
 func (*TelemetryEvent) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{8}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *TelemetryEvent) GetPack() string {
@@ -780,7 +897,7 @@ type TelemetryResponse struct {
 
 func (x *TelemetryResponse) Reset() {
 	*x = TelemetryResponse{}
-	mi := &file_packs_v1_packs_proto_msgTypes[9]
+	mi := &file_packs_v1_packs_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -792,7 +909,7 @@ func (x *TelemetryResponse) String() string {
 func (*TelemetryResponse) ProtoMessage() {}
 
 func (x *TelemetryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[9]
+	mi := &file_packs_v1_packs_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -805,7 +922,7 @@ func (x *TelemetryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TelemetryResponse.ProtoReflect.Descriptor instead.
 func (*TelemetryResponse) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{9}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{10}
 }
 
 // List versions
@@ -818,7 +935,7 @@ type ListVersionsRequest struct {
 
 func (x *ListVersionsRequest) Reset() {
 	*x = ListVersionsRequest{}
-	mi := &file_packs_v1_packs_proto_msgTypes[10]
+	mi := &file_packs_v1_packs_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -830,7 +947,7 @@ func (x *ListVersionsRequest) String() string {
 func (*ListVersionsRequest) ProtoMessage() {}
 
 func (x *ListVersionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[10]
+	mi := &file_packs_v1_packs_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -843,7 +960,7 @@ func (x *ListVersionsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListVersionsRequest.ProtoReflect.Descriptor instead.
 func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{10}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ListVersionsRequest) GetName() string {
@@ -862,7 +979,7 @@ type ListVersionsResponse struct {
 
 func (x *ListVersionsResponse) Reset() {
 	*x = ListVersionsResponse{}
-	mi := &file_packs_v1_packs_proto_msgTypes[11]
+	mi := &file_packs_v1_packs_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -874,7 +991,7 @@ func (x *ListVersionsResponse) String() string {
 func (*ListVersionsResponse) ProtoMessage() {}
 
 func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_packs_v1_packs_proto_msgTypes[11]
+	mi := &file_packs_v1_packs_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -887,7 +1004,7 @@ func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListVersionsResponse.ProtoReflect.Descriptor instead.
 func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
-	return file_packs_v1_packs_proto_rawDescGZIP(), []int{11}
+	return file_packs_v1_packs_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ListVersionsResponse) GetVersions() []string {
@@ -901,7 +1018,7 @@ var File_packs_v1_packs_proto protoreflect.FileDescriptor
 
 const file_packs_v1_packs_proto_rawDesc = "" +
 	"\n" +
-	"\x14packs/v1/packs.proto\x12\bpacks.v1\"\x93\x03\n" +
+	"\x14packs/v1/packs.proto\x12\bpacks.v1\"\xba\x04\n" +
 	"\x04Pack\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12&\n" +
@@ -921,7 +1038,19 @@ const file_packs_v1_packs_proto_rawDesc = "" +
 	"\n" +
 	"updated_at\x18\r \x01(\x03R\tupdatedAt\x12\x1d\n" +
 	"\n" +
-	"source_url\x18\x0e \x01(\tR\tsourceUrl\"\x85\x02\n" +
+	"source_url\x18\x0e \x01(\tR\tsourceUrl\x12\x1c\n" +
+	"\tsignature\x18\x0f \x01(\tR\tsignature\x12\x15\n" +
+	"\x06key_id\x18\x10 \x01(\tR\x05keyId\x12(\n" +
+	"\x05files\x18\x11 \x03(\v2\x12.packs.v1.PackFileR\x05files\x12\x1f\n" +
+	"\varchive_url\x18\x12 \x01(\tR\n" +
+	"archiveUrl\x12%\n" +
+	"\x0earchive_sha256\x18\x13 \x01(\tR\rarchiveSha256\"x\n" +
+	"\bPackFile\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\rR\x04mode\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\fR\acontent\"\x85\x02\n" +
 	"\vPackSummary\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12&\n" +
@@ -999,43 +1128,45 @@ func file_packs_v1_packs_proto_rawDescGZIP() []byte {
 }
 
 var file_packs_v1_packs_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_packs_v1_packs_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_packs_v1_packs_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_packs_v1_packs_proto_goTypes = []any{
 	(PackType)(0),                // 0: packs.v1.PackType
 	(*Pack)(nil),                 // 1: packs.v1.Pack
-	(*PackSummary)(nil),          // 2: packs.v1.PackSummary
-	(*SearchRequest)(nil),        // 3: packs.v1.SearchRequest
-	(*SearchResponse)(nil),       // 4: packs.v1.SearchResponse
-	(*GetRequest)(nil),           // 5: packs.v1.GetRequest
-	(*GetResponse)(nil),          // 6: packs.v1.GetResponse
-	(*SubmitRequest)(nil),        // 7: packs.v1.SubmitRequest
-	(*SubmitResponse)(nil),       // 8: packs.v1.SubmitResponse
-	(*TelemetryEvent)(nil),       // 9: packs.v1.TelemetryEvent
-	(*TelemetryResponse)(nil),    // 10: packs.v1.TelemetryResponse
-	(*ListVersionsRequest)(nil),  // 11: packs.v1.ListVersionsRequest
-	(*ListVersionsResponse)(nil), // 12: packs.v1.ListVersionsResponse
+	(*PackFile)(nil),             // 2: packs.v1.PackFile
+	(*PackSummary)(nil),          // 3: packs.v1.PackSummary
+	(*SearchRequest)(nil),        // 4: packs.v1.SearchRequest
+	(*SearchResponse)(nil),       // 5: packs.v1.SearchResponse
+	(*GetRequest)(nil),           // 6: packs.v1.GetRequest
+	(*GetResponse)(nil),          // 7: packs.v1.GetResponse
+	(*SubmitRequest)(nil),        // 8: packs.v1.SubmitRequest
+	(*SubmitResponse)(nil),       // 9: packs.v1.SubmitResponse
+	(*TelemetryEvent)(nil),       // 10: packs.v1.TelemetryEvent
+	(*TelemetryResponse)(nil),    // 11: packs.v1.TelemetryResponse
+	(*ListVersionsRequest)(nil),  // 12: packs.v1.ListVersionsRequest
+	(*ListVersionsResponse)(nil), // 13: packs.v1.ListVersionsResponse
 }
 var file_packs_v1_packs_proto_depIdxs = []int32{
 	0,  // 0: packs.v1.Pack.type:type_name -> packs.v1.PackType
-	0,  // 1: packs.v1.PackSummary.type:type_name -> packs.v1.PackType
-	0,  // 2: packs.v1.SearchRequest.type:type_name -> packs.v1.PackType
-	2,  // 3: packs.v1.SearchResponse.packs:type_name -> packs.v1.PackSummary
-	1,  // 4: packs.v1.GetResponse.pack:type_name -> packs.v1.Pack
-	3,  // 5: packs.v1.PacksService.Search:input_type -> packs.v1.SearchRequest
-	5,  // 6: packs.v1.PacksService.Get:input_type -> packs.v1.GetRequest
-	7,  // 7: packs.v1.PacksService.Submit:input_type -> packs.v1.SubmitRequest
-	9,  // 8: packs.v1.PacksService.Telemetry:input_type -> packs.v1.TelemetryEvent
-	11, // 9: packs.v1.PacksService.ListVersions:input_type -> packs.v1.ListVersionsRequest
-	4,  // 10: packs.v1.PacksService.Search:output_type -> packs.v1.SearchResponse
-	6,  // 11: packs.v1.PacksService.Get:output_type -> packs.v1.GetResponse
-	8,  // 12: packs.v1.PacksService.Submit:output_type -> packs.v1.SubmitResponse
-	10, // 13: packs.v1.PacksService.Telemetry:output_type -> packs.v1.TelemetryResponse
-	12, // 14: packs.v1.PacksService.ListVersions:output_type -> packs.v1.ListVersionsResponse
-	10, // [10:15] is the sub-list for method output_type
-	5,  // [5:10] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	2,  // 1: packs.v1.Pack.files:type_name -> packs.v1.PackFile
+	0,  // 2: packs.v1.PackSummary.type:type_name -> packs.v1.PackType
+	0,  // 3: packs.v1.SearchRequest.type:type_name -> packs.v1.PackType
+	3,  // 4: packs.v1.SearchResponse.packs:type_name -> packs.v1.PackSummary
+	1,  // 5: packs.v1.GetResponse.pack:type_name -> packs.v1.Pack
+	4,  // 6: packs.v1.PacksService.Search:input_type -> packs.v1.SearchRequest
+	6,  // 7: packs.v1.PacksService.Get:input_type -> packs.v1.GetRequest
+	8,  // 8: packs.v1.PacksService.Submit:input_type -> packs.v1.SubmitRequest
+	10, // 9: packs.v1.PacksService.Telemetry:input_type -> packs.v1.TelemetryEvent
+	12, // 10: packs.v1.PacksService.ListVersions:input_type -> packs.v1.ListVersionsRequest
+	5,  // 11: packs.v1.PacksService.Search:output_type -> packs.v1.SearchResponse
+	7,  // 12: packs.v1.PacksService.Get:output_type -> packs.v1.GetResponse
+	9,  // 13: packs.v1.PacksService.Submit:output_type -> packs.v1.SubmitResponse
+	11, // 14: packs.v1.PacksService.Telemetry:output_type -> packs.v1.TelemetryResponse
+	13, // 15: packs.v1.PacksService.ListVersions:output_type -> packs.v1.ListVersionsResponse
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_packs_v1_packs_proto_init() }
@@ -1049,7 +1180,7 @@ func file_packs_v1_packs_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_packs_v1_packs_proto_rawDesc), len(file_packs_v1_packs_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   12,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,334 @@
+// Package agents knows where each coding agent reads packs from and in what
+// shape. A Target decides whether its agent is set up, which directory packs
+// go into, and how a pack's files are laid out there: a skill directory for
+// Claude Code, a single .mdc rule for Cursor, and so on.
+package agents
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// Scope is where packs go: the project, so they can be committed next to
+// the code, or the user's home directory, for every project
+type Scope string
+
+// Scopes
+const (
+	ScopeProject Scope = "project"
+	ScopeUser    Scope = "user"
+)
+
+// ParseScope parses a --scope value. "" means each agent's default.
+func ParseScope(s string) (Scope, error) {
+	switch Scope(s) {
+	case "", ScopeProject, ScopeUser:
+		return Scope(s), nil
+	}
+	return "", fmt.Errorf("unknown scope: %s\nUse --scope project or --scope user", s)
+}
+
+// Target is an agent packs can install to
+type Target interface {
+	// Name is how the target is chosen with --agent
+	Name() string
+
+	// Title is the agent's display name
+	Title() string
+
+	// Detect reports whether the agent looks set up on this machine or in
+	// this project
+	Detect() bool
+
+	// Scopes lists the scopes the agent reads packs from, its default first
+	Scopes() []Scope
+
+	// Dir is the directory packs are installed into for a scope
+	Dir(scope Scope) string
+
+	// Layout maps a pack onto the files to write, relative to Dir
+	Layout(p *Pack) ([]pack.File, error)
+}
+
+// Location is a target in one scope: where its packs actually go. Scope is
+// empty for directories given with --output.
+type Location struct {
+	Target Target
+	Scope  Scope
+	Dir    string
+}
+
+// Locate finds a target's directory for a scope, or for its default scope
+// when scope is empty
+func Locate(t Target, scope Scope) (Location, error) {
+	scopes := t.Scopes()
+	if scope == "" {
+		scope = scopes[0]
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return Location{Target: t, Scope: scope, Dir: t.Dir(scope)}, nil
+		}
+	}
+	return Location{}, fmt.Errorf("%s has no %s scope\n%s reads packs from the %s only", t.Name(), scope, t.Title(), scopes[0])
+}
+
+// Pack is a fetched pack, ready to be laid out for a target
+type Pack struct {
+	Name        string
+	Version     string
+	Type        string
+	Description string
+	Files       []pack.File
+}
+
+// Main returns the pack's content file
+func (p *Pack) Main() *pack.File {
+	return pack.MainFile(p.Files, p.Type)
+}
+
+// Meta returns the pack's pack.yaml, or an empty one
+func (p *Pack) Meta() *pack.Meta {
+	for _, f := range p.Files {
+		if f.Path == pack.MetaFile {
+			if m, err := pack.ParseMeta(f.Content); err == nil {
+				return m
+			}
+		}
+	}
+	return &pack.Meta{}
+}
+
+// Describe returns a one-line description: the one given, pack.yaml's, or
+// the content's first heading
+func (p *Pack) Describe() string {
+	if p.Description != "" {
+		return p.Description
+	}
+	if d := p.Meta().Description; d != "" {
+		return d
+	}
+	if main := p.Main(); main != nil {
+		for _, line := range strings.Split(string(main.Content), "\n") {
+			if heading, ok := strings.CutPrefix(line, "# "); ok {
+				return strings.TrimSpace(heading)
+			}
+		}
+	}
+	return p.Name
+}
+
+// target is a built-in Target. Clawdbot's workspace is the project, so
+// its default scope is project; agents without user-level files only have
+// one. Project directories are relative to the project root.
+type target struct {
+	name    string
+	title   string
+	scopes  []Scope
+	user    string
+	project string
+	detect  func() bool
+	layout  func(p *Pack) ([]pack.File, error)
+}
+
+func (t *target) Name() string                        { return t.name }
+func (t *target) Title() string                       { return t.title }
+func (t *target) Detect() bool                        { return t.detect() }
+func (t *target) Layout(p *Pack) ([]pack.File, error) { return t.layout(p) }
+
+func (t *target) Scopes() []Scope { return t.scopes }
+
+func (t *target) Dir(scope Scope) string {
+	if scope == ScopeProject {
+		return filepath.Join(ProjectRoot(), t.project)
+	}
+	return t.user
+}
+
+// Builtin returns every built-in target in detection order. Generic comes
+// last and always detects, so there's always somewhere to install.
+func Builtin() []Target {
+	home, _ := os.UserHomeDir()
+
+	return []Target{
+		&target{
+			name:    "claude",
+			title:   "Claude Code",
+			scopes:  []Scope{ScopeUser, ScopeProject},
+			user:    filepath.Join(home, ".claude", "skills"),
+			project: filepath.Join(".claude", "skills"),
+			detect:  func() bool { return dirExists(filepath.Join(home, ".claude")) },
+			layout:  skillLayout,
+		},
+		&target{
+			name:    "clawdbot",
+			title:   "Clawdbot",
+			scopes:  []Scope{ScopeProject, ScopeUser},
+			user:    filepath.Join(home, ".clawdbot", "skills"),
+			project: "skills",
+			detect: func() bool {
+				return dirExists("skills") || fileExists("AGENTS.md") || fileExists("SOUL.md")
+			},
+			layout: skillLayout,
+		},
+		&target{
+			name:    "codex",
+			title:   "Codex",
+			scopes:  []Scope{ScopeUser, ScopeProject},
+			user:    filepath.Join(home, ".codex", "skills"),
+			project: filepath.Join(".codex", "skills"),
+			detect:  func() bool { return dirExists(filepath.Join(home, ".codex")) },
+			layout:  skillLayout,
+		},
+		&target{
+			name:    "cursor",
+			title:   "Cursor",
+			scopes:  []Scope{ScopeProject},
+			project: filepath.Join(".cursor", "rules"),
+			detect:  func() bool { return dirExists(".cursor") || dirExists(filepath.Join(home, ".cursor")) },
+			layout:  cursorLayout,
+		},
+		&target{
+			name:    "copilot",
+			title:   "GitHub Copilot",
+			scopes:  []Scope{ScopeProject},
+			project: filepath.Join(".github", "instructions"),
+			detect: func() bool {
+				return dirExists(filepath.Join(".github", "instructions")) || fileExists(filepath.Join(".github", "copilot-instructions.md"))
+			},
+			layout: copilotLayout,
+		},
+		&target{
+			name:    "gemini",
+			title:   "Gemini CLI",
+			scopes:  []Scope{ScopeUser, ScopeProject},
+			user:    filepath.Join(home, ".gemini", "extensions"),
+			project: filepath.Join(".gemini", "extensions"),
+			detect:  func() bool { return dirExists(filepath.Join(home, ".gemini")) },
+			layout:  geminiLayout,
+		},
+		&target{
+			name:    "aider",
+			title:   "Aider",
+			scopes:  []Scope{ScopeProject},
+			project: "conventions",
+			detect: func() bool {
+				return fileExists(".aider.conf.yml") || fileExists(filepath.Join(home, ".aider.conf.yml"))
+			},
+			layout: aiderLayout,
+		},
+		&target{
+			name:    "generic",
+			title:   "Generic",
+			scopes:  []Scope{ScopeUser, ScopeProject},
+			user:    filepath.Join(home, ".packs", "skills"),
+			project: filepath.Join(".packs", "skills"),
+			detect:  func() bool { return true },
+			layout:  skillLayout,
+		},
+	}
+}
+
+// Directory is a location that installs packs as skill directories into
+// dir, for --output and a configured skills_dir
+func Directory(name string, scope Scope, dir string) Location {
+	return Location{
+		Target: &target{
+			name:   name,
+			title:  "Directory",
+			scopes: []Scope{ScopeUser},
+			user:   dir,
+			detect: func() bool { return true },
+			layout: skillLayout,
+		},
+		Scope: scope,
+		Dir:   dir,
+	}
+}
+
+// ProjectRoot is the directory project-scope packs are installed under: the
+// nearest one up from the current directory with a packs.yaml, a
+// .packs/config.yaml or a .git, or else the current directory. It's "."
+// when that's where packs runs.
+func ProjectRoot() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	home, _ := os.UserHomeDir()
+	for dir := cwd; dir != home; dir = filepath.Dir(dir) {
+		for _, marker := range []string{"packs.yaml", filepath.Join(".packs", "config.yaml"), ".git"} {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				if dir == cwd {
+					return "."
+				}
+				return dir
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return "."
+}
+
+// Lookup finds a built-in target by name
+func Lookup(name string) (Target, error) {
+	for _, t := range Builtin() {
+		if t.Name() == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown agent: %s\nKnown agents: %s", name, strings.Join(Names(), ", "))
+}
+
+// Names lists the built-in target names
+func Names() []string {
+	var names []string
+	for _, t := range Builtin() {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// Detect returns where packs go by default: the first detected built-in
+// target that has the scope, or its default scope when scope is empty
+func Detect(scope Scope) Location {
+	targets := Builtin()
+	for _, t := range targets {
+		if !t.Detect() {
+			continue
+		}
+		if loc, err := Locate(t, scope); err == nil {
+			return loc
+		}
+	}
+	loc, _ := Locate(targets[len(targets)-1], scope)
+	return loc
+}
+
+// Locations lists every built-in target in every scope it has, in
+// detection order
+func Locations() []Location {
+	var locs []Location
+	for _, t := range Builtin() {
+		for _, scope := range t.Scopes() {
+			locs = append(locs, Location{Target: t, Scope: scope, Dir: t.Dir(scope)})
+		}
+	}
+	return locs
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
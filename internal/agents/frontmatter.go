@@ -0,0 +1,104 @@
+package agents
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// field is a frontmatter key an agent needs. Fields with replace set
+// override what the author wrote; the others only fill in missing keys.
+type field struct {
+	key     string
+	value   any
+	replace bool
+}
+
+// withFrontmatter merges fields into the YAML frontmatter at the top of
+// content, adding a frontmatter block when there is none. Keys the author
+// wrote keep their order and comments; new keys are appended.
+func withFrontmatter(content []byte, fields []field) ([]byte, error) {
+	doc, body, err := splitFrontmatter(content)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = &yaml.Node{Kind: yaml.MappingNode}
+		body = append([]byte("\n"), content...)
+	}
+
+	for _, f := range fields {
+		var value yaml.Node
+		if err := value.Encode(f.value); err != nil {
+			return nil, err
+		}
+		if i := keyIndex(doc, f.key); i != -1 {
+			if f.replace {
+				doc.Content[i+1] = &value
+			}
+			continue
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.key}, &value)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	buf.WriteString("---\n")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// splitFrontmatter parses the frontmatter mapping at the top of content and
+// returns it with the rest of the content, or a nil mapping when there is
+// no frontmatter
+func splitFrontmatter(content []byte) (*yaml.Node, []byte, error) {
+	normalized := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(normalized, []byte("---\n")) {
+		return nil, content, nil
+	}
+	rest := normalized[len("---\n"):]
+
+	var end, next int
+	switch {
+	case bytes.HasPrefix(rest, []byte("---\n")):
+		end, next = 0, len("---\n")
+	default:
+		i := bytes.Index(rest, []byte("\n---\n"))
+		if i == -1 {
+			if !bytes.HasSuffix(rest, []byte("\n---")) {
+				return nil, content, nil
+			}
+			i = len(rest) - len("\n---")
+		}
+		end, next = i+1, min(i+len("\n---\n"), len(rest))
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(rest[:end], &doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	if doc.Kind == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode}, rest[next:], nil
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("invalid frontmatter: not a mapping")
+	}
+	return doc.Content[0], rest[next:], nil
+}
+
+// keyIndex returns the index of key in a mapping node's content, or -1
+func keyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,86 @@
+package agents
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// inlineTarget writes a pack's content as a managed block into shared
+// files, like AGENTS.md or CLAUDE.md, instead of owning files of its own
+type inlineTarget struct {
+	dir   string
+	scope Scope
+	files []string
+}
+
+func (t *inlineTarget) Name() string           { return "inline" }
+func (t *inlineTarget) Title() string          { return "Managed block" }
+func (t *inlineTarget) Detect() bool           { return true }
+func (t *inlineTarget) Scopes() []Scope        { return []Scope{t.scope} }
+func (t *inlineTarget) Dir(scope Scope) string { return t.dir }
+
+// Layout returns the block content for each file: the pack's content
+// without frontmatter, which means nothing inside another file
+func (t *inlineTarget) Layout(p *Pack) ([]pack.File, error) {
+	main := p.Main()
+	if main == nil {
+		return nil, errNoContent(p)
+	}
+	_, body, err := splitFrontmatter(main.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", main.Path, err)
+	}
+
+	var files []pack.File
+	for _, file := range t.files {
+		files = append(files, pack.File{Path: file, Mode: 0644, Content: []byte(strings.TrimLeft(string(body), "\n"))})
+	}
+	return files, nil
+}
+
+// Inline is a location that writes packs as managed blocks into files in
+// dir, named relative to it
+func Inline(dir string, scope Scope, files ...string) Location {
+	return Location{Target: &inlineTarget{dir: dir, scope: scope, files: files}, Scope: scope, Dir: dir}
+}
+
+// InlineFile is the location for --inline: a managed block in one file, in
+// project scope when the file is inside the project
+func InlineFile(path string) Location {
+	dir, file := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	scope := ScopeUser
+	abs, _ := filepath.Abs(path)
+	root, _ := filepath.Abs(ProjectRoot())
+	if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		scope = ScopeProject
+	}
+	return Inline(filepath.Clean(dir), scope, file)
+}
+
+// IsInline reports whether a target writes managed blocks
+func IsInline(t Target) bool {
+	_, ok := t.(*inlineTarget)
+	return ok
+}
+
+// InlineLocations lists the directories whose files usually hold managed
+// blocks: the project root (AGENTS.md, CLAUDE.md, GEMINI.md) and the
+// agents' user directories (~/.claude/CLAUDE.md, ~/.codex/AGENTS.md,
+// ~/.gemini/GEMINI.md)
+func InlineLocations() []Location {
+	home, _ := os.UserHomeDir()
+	return []Location{
+		Inline(ProjectRoot(), ScopeProject),
+		Inline(filepath.Join(home, ".claude"), ScopeUser),
+		Inline(filepath.Join(home, ".codex"), ScopeUser),
+		Inline(filepath.Join(home, ".gemini"), ScopeUser),
+	}
+}
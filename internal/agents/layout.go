@@ -0,0 +1,158 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// dirLayout installs a pack as-is into <name>/, the Agent Skills layout
+func dirLayout(p *Pack) ([]pack.File, error) {
+	files := make([]pack.File, 0, len(p.Files))
+	for _, f := range p.Files {
+		f.Path = p.Name + "/" + f.Path
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// fileLayout installs a pack's content as one file, with its supporting
+// files (scripts, references) in <name>/ next to it
+func fileLayout(p *Pack, file string, content []byte) []pack.File {
+	main := p.Main()
+	files := []pack.File{{Path: file, Mode: 0644, Content: content}}
+	for _, f := range p.Files {
+		if f.Path == main.Path || f.Path == pack.MetaFile {
+			continue
+		}
+		f.Path = p.Name + "/" + f.Path
+		files = append(files, f)
+	}
+	return files
+}
+
+// skillLayout installs a pack into <name>/ like dirLayout, with the name
+// and description agents discover skills by in SKILL.md's frontmatter. The
+// name must match the directory, so it replaces the author's.
+func skillLayout(p *Pack) ([]pack.File, error) {
+	files, _ := dirLayout(p)
+	for i, f := range files {
+		if f.Path != p.Name+"/SKILL.md" {
+			continue
+		}
+		content, err := withFrontmatter(f.Content, []field{
+			{key: "name", value: p.Name, replace: true},
+			{key: "description", value: p.Describe()},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Path, err)
+		}
+		files[i].Content = content
+	}
+	return files, nil
+}
+
+// cursorLayout writes a pack as .cursor/rules/<name>.mdc. Rules with globs
+// attach to matching files; otherwise context packs apply to every request
+// and skills are picked by description, unless pack.yaml says always_apply.
+func cursorLayout(p *Pack) ([]pack.File, error) {
+	main := p.Main()
+	if main == nil {
+		return nil, errNoContent(p)
+	}
+	meta := p.Meta()
+	alwaysApply := p.Type == "context" && len(meta.Globs) == 0
+	if meta.AlwaysApply != nil {
+		alwaysApply = *meta.AlwaysApply
+	}
+
+	fields := []field{{key: "description", value: p.Describe()}}
+	if len(meta.Globs) > 0 {
+		fields = append(fields, field{key: "globs", value: strings.Join(meta.Globs, ",")})
+	}
+	fields = append(fields, field{key: "alwaysApply", value: alwaysApply})
+
+	content, err := withFrontmatter(main.Content, fields)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", main.Path, err)
+	}
+	return fileLayout(p, p.Name+".mdc", content), nil
+}
+
+// copilotLayout writes a pack as .github/instructions/<name>.instructions.md,
+// applied to the files pack.yaml's globs match, or to every file
+func copilotLayout(p *Pack) ([]pack.File, error) {
+	main := p.Main()
+	if main == nil {
+		return nil, errNoContent(p)
+	}
+	applyTo := "**"
+	if globs := p.Meta().Globs; len(globs) > 0 {
+		applyTo = strings.Join(globs, ",")
+	}
+
+	content, err := withFrontmatter(main.Content, []field{
+		{key: "description", value: p.Describe()},
+		{key: "applyTo", value: applyTo},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", main.Path, err)
+	}
+	return fileLayout(p, p.Name+".instructions.md", content), nil
+}
+
+// geminiExtension is a Gemini CLI gemini-extension.json
+type geminiExtension struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	ContextFileName string `json:"contextFileName"`
+}
+
+// geminiLayout installs a pack as a Gemini CLI extension whose context file
+// is the pack's content, unless the pack ships its own manifest
+func geminiLayout(p *Pack) ([]pack.File, error) {
+	main := p.Main()
+	if main == nil {
+		return nil, errNoContent(p)
+	}
+	version := p.Version
+	if version == "" {
+		version = "0.0.0"
+	}
+	manifest, err := json.MarshalIndent(geminiExtension{
+		Name:            p.Name,
+		Version:         version,
+		ContextFileName: main.Path,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	files, _ := dirLayout(p)
+	for _, f := range p.Files {
+		if f.Path == "gemini-extension.json" {
+			return files, nil
+		}
+	}
+	return append(files, pack.File{
+		Path:    p.Name + "/gemini-extension.json",
+		Mode:    0644,
+		Content: append(manifest, '\n'),
+	}), nil
+}
+
+// aiderLayout writes a pack as conventions/<name>.md, for Aider's read:
+// setting
+func aiderLayout(p *Pack) ([]pack.File, error) {
+	main := p.Main()
+	if main == nil {
+		return nil, errNoContent(p)
+	}
+	return fileLayout(p, p.Name+".md", main.Content), nil
+}
+
+func errNoContent(p *Pack) error {
+	return fmt.Errorf("%s has no content file\nTried: SKILL.md, CONTEXT.md, PROMPT.md", p.Name)
+}
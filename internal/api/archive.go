@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tunajam/packs/internal/archive"
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// archiveClient downloads pack archives. It's separate from the registry
+// client so auth tokens never go to the archive host.
+var archiveClient = &http.Client{Timeout: 5 * time.Minute}
+
+// DownloadArchive fetches an archive over HTTP(S), capped at archive.MaxSize
+func DownloadArchive(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", ClientVersion)
+
+	resp, err := archiveClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	return archive.ReadAll(resp.Body)
+}
+
+// fetchArchive downloads a pack's archive, checks it against the declared
+// SHA-256 and fills in the pack's files. When the registry sent a file
+// manifest, exactly those files are taken from the archive; their hashes are
+// checked by VerifyContent like any other files.
+func fetchArchive(ctx context.Context, p *Pack, url, declared string) error {
+	data, err := DownloadArchive(ctx, url)
+	if err != nil {
+		return fmt.Errorf("%s@%s: %w", p.Name, p.Version, err)
+	}
+
+	if declared != "" {
+		sum := sha256.Sum256(data)
+		got := hex.EncodeToString(sum[:])
+		if want := strings.TrimPrefix(strings.ToLower(declared), "sha256:"); got != want {
+			return fmt.Errorf("%w for %s@%s archive\n  expected: %s\n  got:      %s", ErrHashMismatch, p.Name, p.Version, want, got)
+		}
+	}
+
+	contents, err := archive.Extract(data, archive.Options{Limits: archive.DefaultLimits})
+	if err != nil {
+		return fmt.Errorf("%s@%s: %w", p.Name, p.Version, err)
+	}
+
+	if len(p.Files) == 0 {
+		p.Files = contents.Files
+	} else {
+		extracted := map[string][]byte{}
+		for _, f := range contents.Files {
+			extracted[f.Path] = f.Content
+		}
+		for i, f := range p.Files {
+			content, ok := extracted[f.Path]
+			if !ok {
+				return fmt.Errorf("%w for %s@%s: %s is missing from the archive", ErrHashMismatch, p.Name, p.Version, f.Path)
+			}
+			p.Files[i].Content = content
+		}
+	}
+
+	if p.Content == "" {
+		if main := pack.MainFile(p.Files, p.Type); main != nil {
+			p.Content = string(main.Content)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tunajam/packs/internal/cache"
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// ErrOffline is returned in offline mode when the cache can't answer
+var ErrOffline = errors.New("not available offline")
+
+// CacheMode controls how a CachedStore uses the cache
+type CacheMode int
+
+const (
+	// CacheNormal serves fresh entries from the cache and refreshes stale
+	// ones from the store, falling back to stale entries if the store fails
+	CacheNormal CacheMode = iota
+	// CacheBypass always asks the store (--no-cache); results still refresh
+	// the cache
+	CacheBypass
+	// CacheOffline only serves from the cache, fresh or stale (--offline)
+	CacheOffline
+)
+
+// CachedStore wraps a PackStore with the local cache. Keys are prefixed with
+// the registry name so several registries can share one cache.
+type CachedStore struct {
+	store    PackStore
+	cache    *cache.Cache
+	registry string
+	mode     CacheMode
+}
+
+// cachedPack is how a Pack is stored: metadata in the entry, content and
+// files as blobs addressed by their hash
+type cachedPack struct {
+	Pack  *Pack    `json:"pack"`
+	Blob  string   `json:"blob"`
+	Files []string `json:"files,omitempty"` // blob of each of Pack.Files
+}
+
+// NewCachedStore wraps store with c
+func NewCachedStore(store PackStore, c *cache.Cache, registry string, mode CacheMode) *CachedStore {
+	return &CachedStore{store: store, cache: c, registry: registry, mode: mode}
+}
+
+// Search serves search results from the cache when fresh
+func (s *CachedStore) Search(ctx context.Context, opts SearchOpts) ([]PackSummary, int32, error) {
+	keyData, _ := json.Marshal(opts)
+	key := s.registry + "|" + string(keyData)
+
+	type searchResult struct {
+		Packs []PackSummary `json:"packs"`
+		Total int32         `json:"total"`
+	}
+
+	var cached searchResult
+	entry, cacheErr := s.cache.Get(cache.KindSearch, key, &cached)
+	if cacheErr == nil && s.serveCached(entry) {
+		return cached.Packs, cached.Total, nil
+	}
+	if s.mode == CacheOffline {
+		return nil, 0, fmt.Errorf("%w: search in %s", ErrOffline, s.registry)
+	}
+
+	packs, total, err := s.store.Search(ctx, opts)
+	if err != nil {
+		if cacheErr == nil && s.mode == CacheNormal {
+			return cached.Packs, cached.Total, nil
+		}
+		return nil, 0, err
+	}
+
+	s.cache.Put(cache.KindSearch, key, searchResult{Packs: packs, Total: total}, cache.Entry{})
+	return packs, total, nil
+}
+
+// Get serves packs from the cache when fresh. Content is stored and looked
+// up by its SHA-256.
+func (s *CachedStore) Get(ctx context.Context, name, version string) (*Pack, error) {
+	key := s.packKey(name, version)
+
+	if p, entry, err := s.cachedPack(key); err == nil && s.serveCached(entry) {
+		return p, nil
+	}
+	if s.mode == CacheOffline {
+		return nil, fmt.Errorf("%w: %s", ErrOffline, packRef(name, version))
+	}
+
+	p, err := s.store.Get(ctx, name, version)
+	if err != nil {
+		if errors.Is(err, ErrHashMismatch) || errors.Is(err, pack.ErrUnsafePath) {
+			return nil, err
+		}
+		if cached, _, cacheErr := s.cachedPack(key); cacheErr == nil && s.mode == CacheNormal {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	s.putPack(p, version)
+	return p, nil
+}
+
+// ListVersions serves version lists from the cache when fresh
+func (s *CachedStore) ListVersions(ctx context.Context, name string) ([]string, error) {
+	key := s.registry + "|" + name
+
+	var cached []string
+	entry, cacheErr := s.cache.Get(cache.KindVersions, key, &cached)
+	if cacheErr == nil && s.serveCached(entry) {
+		return cached, nil
+	}
+	if s.mode == CacheOffline {
+		return nil, fmt.Errorf("%w: versions of %s", ErrOffline, name)
+	}
+
+	versions, err := s.store.ListVersions(ctx, name)
+	if err != nil {
+		if cacheErr == nil && s.mode == CacheNormal {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	s.cache.Put(cache.KindVersions, key, versions, cache.Entry{Name: name})
+	return versions, nil
+}
+
+// Submit always goes to the store
+func (s *CachedStore) Submit(ctx context.Context, githubRef string) (string, string, string, error) {
+	if s.mode == CacheOffline {
+		return "", "", "", fmt.Errorf("%w: submit", ErrOffline)
+	}
+	return s.store.Submit(ctx, githubRef)
+}
+
+// Telemetry forwards to the wrapped store, except offline
+func (s *CachedStore) Telemetry(ctx context.Context, pack, source, version, cliVersion, os, arch string) {
+	if sink, ok := s.store.(TelemetrySink); ok && s.mode != CacheOffline {
+		sink.Telemetry(ctx, pack, source, version, cliVersion, os, arch)
+	}
+}
+
+func (s *CachedStore) serveCached(entry *cache.Entry) bool {
+	switch s.mode {
+	case CacheOffline:
+		return true
+	case CacheBypass:
+		return false
+	default:
+		return s.cache.Fresh(entry)
+	}
+}
+
+func (s *CachedStore) packKey(name, version string) string {
+	if version == "" {
+		version = "latest"
+	}
+	return s.registry + "|" + name + "@" + version
+}
+
+func (s *CachedStore) cachedPack(key string) (*Pack, *cache.Entry, error) {
+	var cp cachedPack
+	entry, err := s.cache.Get(cache.KindPack, key, &cp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cp.Pack == nil {
+		return nil, nil, cache.ErrMiss
+	}
+	content, err := s.cache.Content(cp.Blob)
+	if err != nil {
+		return nil, nil, err
+	}
+	cp.Pack.Content = string(content)
+	if len(cp.Files) != len(cp.Pack.Files) {
+		return nil, nil, cache.ErrCorrupt
+	}
+	for i, hash := range cp.Files {
+		data, err := s.cache.Content(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		cp.Pack.Files[i].Content = data
+	}
+	// A corrupted blob is a miss, so it gets fetched again
+	if VerifyContent(cp.Pack) != nil {
+		return nil, nil, cache.ErrCorrupt
+	}
+	return cp.Pack, entry, nil
+}
+
+// putPack stores a pack under the requested version and, when that was a
+// range or "latest", under its resolved version too
+func (s *CachedStore) putPack(p *Pack, requested string) {
+	blob, err := s.cache.PutContent([]byte(p.Content))
+	if err != nil {
+		return
+	}
+
+	meta := *p
+	meta.Content = ""
+	meta.Files = nil
+	cp := cachedPack{Pack: &meta, Blob: blob}
+	for _, f := range p.Files {
+		hash, err := s.cache.PutContent(f.Content)
+		if err != nil {
+			return
+		}
+		f.Content = nil
+		meta.Files = append(meta.Files, f)
+		cp.Files = append(cp.Files, hash)
+	}
+	entry := cache.Entry{Name: p.Name, Version: p.Version, ContentHash: blob, Blobs: cp.Files}
+
+	s.cache.Put(cache.KindPack, s.packKey(p.Name, requested), cp, entry)
+	if requested != p.Version && p.Version != "" {
+		s.cache.Put(cache.KindPack, s.packKey(p.Name, p.Version), cp, entry)
+	}
+}
+
+// SearchCache searches everything in the cache, fresh or stale: cached
+// search results and cached packs. It backs find in offline mode.
+func SearchCache(c *cache.Cache, opts SearchOpts) []PackSummary {
+	entries, _ := c.Entries("")
+
+	seen := map[string]bool{}
+	var all []PackSummary
+	add := func(p PackSummary) {
+		if !seen[p.Name] {
+			seen[p.Name] = true
+			all = append(all, p)
+		}
+	}
+
+	// Search results carry stars, so they go first; entries are newest
+	// first, so the newest copy of each pack wins
+	for _, e := range entries {
+		if e.Kind != cache.KindSearch {
+			continue
+		}
+		var res struct {
+			Packs []PackSummary `json:"packs"`
+		}
+		if json.Unmarshal(e.Data, &res) == nil {
+			for _, p := range res.Packs {
+				if p.Registry == "" {
+					p.Registry = registryFromKey(e.Key)
+				}
+				add(p)
+			}
+		}
+	}
+	for _, e := range entries {
+		if e.Kind != cache.KindPack {
+			continue
+		}
+		var cp cachedPack
+		if json.Unmarshal(e.Data, &cp) == nil && cp.Pack != nil {
+			p := cp.Pack.PackSummary
+			p.Registry = registryFromKey(e.Key)
+			add(p)
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(opts.Query))
+	var results []PackSummary
+	for _, p := range all {
+		if opts.Type != "" && p.Type != opts.Type {
+			continue
+		}
+		if !hasAllTags(p.Tags, opts.Tags) {
+			continue
+		}
+		if !summaryMatches(p, words) {
+			continue
+		}
+		results = append(results, p)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Stars != results[j].Stars {
+			return results[i].Stars > results[j].Stars
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	if opts.Limit > 0 && len(results) > int(opts.Limit) {
+		results = results[:opts.Limit]
+	}
+	return results
+}
+
+func summaryMatches(p PackSummary, words []string) bool {
+	name := strings.ToLower(p.Name)
+	desc := strings.ToLower(p.Description)
+	for _, w := range words {
+		if !strings.Contains(name, w) && !strings.Contains(desc, w) && !hasTagContaining(p.Tags, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func registryFromKey(key string) string {
+	if i := strings.Index(key, "|"); i != -1 {
+		return key[:i]
+	}
+	return ""
+}
+
+func packRef(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "@" + version
+}
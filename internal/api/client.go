@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"connectrpc.com/connect"
 	packsv1 "github.com/tunajam/packs/gen/packs/v1"
 	"github.com/tunajam/packs/gen/packs/v1/packsv1connect"
+	"github.com/tunajam/packs/internal/pack"
 )
 
 const (
@@ -31,7 +33,7 @@ func (t *clientHeaderTransport) RoundTrip(req *http.Request) (*http.Response, er
 	return t.rt.RoundTrip(req)
 }
 
-// Client wraps the Packs API
+// Client wraps the Packs API. It is the Connect-backed PackStore.
 type Client struct {
 	client packsv1connect.PacksServiceClient
 }
@@ -47,7 +49,11 @@ func NewWithAuth(authToken string) *Client {
 	if baseURL == "" {
 		baseURL = DefaultBaseURL
 	}
+	return NewWithURL(baseURL, authToken)
+}
 
+// NewWithURL creates a new API client for a specific registry URL
+func NewWithURL(baseURL, authToken string) *Client {
 	httpClient := &http.Client{
 		Timeout: 30 * time.Second,
 		Transport: &clientHeaderTransport{
@@ -82,13 +88,31 @@ type PackSummary struct {
 	Stars       int32
 	Tags        []string
 	SourceURL   string
+	Registry    string // Registry the pack was resolved from
 }
 
 // Pack represents a full pack with content
 type Pack struct {
 	PackSummary
-	Content   string
-	GithubRef string
+	License     string
+	Content     string
+	ContentHash string
+	GithubRef   string
+	Signature   string // Base64 ed25519 signature, empty when unsigned
+	KeyID       string // Key that made the signature
+	Files       []pack.File
+}
+
+// FileSet returns the pack's files. Registries that only serve the main
+// content get a single file named for the pack type.
+func (p *Pack) FileSet() []pack.File {
+	if len(p.Files) > 0 {
+		return p.Files
+	}
+	if p.Content == "" {
+		return nil
+	}
+	return []pack.File{{Path: pack.ContentFile(p.Type), Mode: 0644, Content: []byte(p.Content)}}
 }
 
 // Search searches for packs
@@ -147,11 +171,11 @@ func (c *Client) Get(ctx context.Context, name, version string) (*Pack, error) {
 
 	resp, err := c.client.Get(ctx, connect.NewRequest(req))
 	if err != nil {
-		return nil, err
+		return nil, storeError(err, packRef(name, version))
 	}
 
 	p := resp.Msg.Pack
-	return &Pack{
+	pk := &Pack{
 		PackSummary: PackSummary{
 			Name:        p.Name,
 			Version:     p.Version,
@@ -160,10 +184,68 @@ func (c *Client) Get(ctx context.Context, name, version string) (*Pack, error) {
 			Author:      p.Author,
 			Stars:       p.Stars,
 			Tags:        p.Tags,
+			SourceURL:   p.SourceUrl,
 		},
-		Content:   p.Content,
-		GithubRef: p.GithubRef,
-	}, nil
+		License:     p.License,
+		Content:     p.Content,
+		ContentHash: p.ContentHash,
+		GithubRef:   p.GithubRef,
+		Signature:   p.Signature,
+		KeyID:       p.KeyId,
+	}
+	for _, f := range p.Files {
+		pk.Files = append(pk.Files, pack.File{
+			Path:    f.Path,
+			Mode:    pack.NormalizeMode(f.Mode),
+			SHA256:  f.Sha256,
+			Content: f.Content,
+		})
+	}
+
+	// Large packs come as one archive; the files above are then its manifest
+	if p.ArchiveUrl != "" {
+		if err := fetchArchive(ctx, pk, p.ArchiveUrl, p.ArchiveSha256); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, f := range p.Files {
+		if got := int64(len(pk.Files[i].Content)); f.Size != 0 && f.Size != got {
+			return nil, fmt.Errorf("%w for %s@%s: %s is %d bytes, expected %d", ErrHashMismatch, p.Name, p.Version, f.Path, got, f.Size)
+		}
+	}
+
+	// Never hand out content that doesn't match what the registry declared
+	if err := VerifyContent(pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// ListVersions lists all published versions of a pack
+func (c *Client) ListVersions(ctx context.Context, name string) ([]string, error) {
+	req := &packsv1.ListVersionsRequest{
+		Name: name,
+	}
+
+	resp, err := c.client.ListVersions(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, storeError(err, name)
+	}
+
+	return resp.Msg.Versions, nil
+}
+
+// storeError maps the registry's not found and unimplemented answers to
+// ErrNotFound and ErrNotSupported, so callers can tell them from failures
+func storeError(err error, ref string) error {
+	switch connect.CodeOf(err) {
+	case connect.CodeNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, ref)
+	case connect.CodeUnimplemented:
+		return fmt.Errorf("%w: %v", ErrNotSupported, err)
+	}
+	return err
 }
 
 // Submit submits a GitHub pack for indexing
@@ -0,0 +1,448 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/signing"
+)
+
+// LocalStore serves a directory laid out like this repo's registry/ folder
+// as a complete registry: <root>/<name>/pack.yaml plus its content file.
+//
+// Older versions are found in <root>/<name>/<version>/ subfolders, or in git
+// tags named <name>@<version> or <name>/v<version> when root is inside a git
+// checkout.
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore creates a store backed by a registry directory
+func NewLocalStore(root string) *LocalStore {
+	return &LocalStore{root: root}
+}
+
+// Root returns the registry directory
+func (s *LocalStore) Root() string {
+	return s.root
+}
+
+// Search searches pack.yaml metadata by name, description, tags and type
+func (s *LocalStore) Search(ctx context.Context, opts SearchOpts) ([]PackSummary, int32, error) {
+	metas, err := s.list()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	words := strings.Fields(strings.ToLower(opts.Query))
+
+	type hit struct {
+		meta  *pack.Meta
+		score int
+	}
+	var hits []hit
+	for _, m := range metas {
+		if opts.Type != "" && m.Type != opts.Type {
+			continue
+		}
+		if opts.Author != "" && !strings.EqualFold(m.Author, opts.Author) {
+			continue
+		}
+		if !hasAllTags(m.Tags, opts.Tags) {
+			continue
+		}
+		score, ok := matchScore(m, words)
+		if !ok {
+			continue
+		}
+		hits = append(hits, hit{meta: m, score: score})
+	}
+
+	// There are no stars locally, so relevance (or name) is the only order
+	sort.SliceStable(hits, func(i, j int) bool {
+		if opts.Sort != "name" && hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].meta.Name < hits[j].meta.Name
+	})
+
+	total := int32(len(hits))
+	if opts.Offset > 0 {
+		if int(opts.Offset) >= len(hits) {
+			hits = nil
+		} else {
+			hits = hits[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && len(hits) > int(opts.Limit) {
+		hits = hits[:opts.Limit]
+	}
+
+	var packs []PackSummary
+	for _, h := range hits {
+		packs = append(packs, summaryFromMeta(h.meta))
+	}
+	return packs, total, nil
+}
+
+// Get reads a pack from the registry directory. An empty version returns
+// the top-level pack; other versions come from subfolders or git tags, and
+// have to be exact.
+func (s *LocalStore) Get(ctx context.Context, name, version string) (*Pack, error) {
+	dir, err := s.packDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := pack.LoadMeta(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	if version == "" || version == m.Version {
+		return readLocalPack(dir, m)
+	}
+	if !IsExactVersion(version) {
+		return nil, fmt.Errorf("invalid version %q of %s\nUse an exact version, like 1.2.0", version, name)
+	}
+
+	if vdir, ok := versionDirs(dir)[version]; ok {
+		vm, err := pack.LoadMeta(vdir)
+		if err != nil {
+			return nil, err
+		}
+		if vm.Name == "" {
+			vm.Name = m.Name
+		}
+		return readLocalPack(vdir, vm)
+	}
+
+	if p, err := s.getFromTag(ctx, dir, name, version); err == nil {
+		return p, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+}
+
+// ListVersions lists the top-level version, version subfolders and git tags
+func (s *LocalStore) ListVersions(ctx context.Context, name string) ([]string, error) {
+	dir, err := s.packDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := pack.LoadMeta(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	seen := map[string]bool{}
+	var versions []string
+	add := func(v string) {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+
+	add(m.Version)
+	for v := range versionDirs(dir) {
+		add(v)
+	}
+	for _, tag := range s.gitTags(ctx, name) {
+		if IsExactVersion(tag.version) {
+			add(tag.version)
+		}
+	}
+
+	return SortVersions(versions), nil
+}
+
+// versionDirs maps each version in a pack's subfolders to its folder: the
+// version in its pack.yaml, or else the folder's name. Only exact versions
+// count.
+func versionDirs(dir string) map[string]string {
+	dirs := map[string]string{}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		vdir := filepath.Join(dir, e.Name())
+		vm, err := pack.LoadMeta(vdir)
+		if err != nil {
+			continue
+		}
+		v := vm.Version
+		if v == "" {
+			v = e.Name()
+		}
+		if IsExactVersion(v) {
+			dirs[v] = vdir
+		}
+	}
+	return dirs
+}
+
+// Submit is not supported: packs are added by committing to the directory
+func (s *LocalStore) Submit(ctx context.Context, githubRef string) (string, string, string, error) {
+	return "", "", "", fmt.Errorf("%w: add the pack to %s instead", ErrNotSupported, s.root)
+}
+
+// list loads pack.yaml for every pack directory directly under root
+func (s *LocalStore) list() ([]*pack.Meta, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry: %w", err)
+	}
+
+	var metas []*pack.Meta
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		m, err := pack.LoadMeta(filepath.Join(s.root, e.Name()))
+		if err != nil {
+			continue
+		}
+		if m.Name == "" {
+			m.Name = e.Name()
+		}
+		metas = append(metas, m)
+	}
+	return metas, nil
+}
+
+func (s *LocalStore) packDir(name string) (string, error) {
+	if !pack.ValidName(name) {
+		return "", fmt.Errorf("%w: %q", pack.ErrInvalidName, name)
+	}
+	return filepath.Join(s.root, name), nil
+}
+
+type localTag struct {
+	tag     string
+	version string
+}
+
+// gitTags lists tags for a pack: <name>@<version> or <name>/v<version>
+func (s *LocalStore) gitTags(ctx context.Context, name string) []localTag {
+	out, err := exec.CommandContext(ctx, "git", "-C", s.root, "tag", "--list",
+		name+"@*", name+"/v*").Output()
+	if err != nil {
+		return nil
+	}
+
+	var tags []localTag
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, name+"@"):
+			tags = append(tags, localTag{tag: line, version: line[len(name)+1:]})
+		case strings.HasPrefix(line, name+"/v"):
+			tags = append(tags, localTag{tag: line, version: line[len(name)+2:]})
+		}
+	}
+	return tags
+}
+
+// getFromTag reads a pack as it was at a git tag
+func (s *LocalStore) getFromTag(ctx context.Context, dir, name, version string) (*Pack, error) {
+	var tag string
+	for _, t := range s.gitTags(ctx, name) {
+		if t.version == version {
+			tag = t.tag
+			break
+		}
+	}
+	if tag == "" {
+		return nil, fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+
+	// Paths in "git show <tag>:<path>" are relative to the repository root
+	prefix, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		return nil, err
+	}
+	rel := strings.TrimSpace(string(prefix))
+
+	show := func(file string) ([]byte, error) {
+		return exec.CommandContext(ctx, "git", "-C", s.root, "show", tag+":"+rel+file).Output()
+	}
+
+	data, err := show(pack.MetaFile)
+	if err != nil {
+		return nil, err
+	}
+	m, err := pack.ParseMeta(data)
+	if err != nil {
+		return nil, err
+	}
+	if m.Name == "" {
+		m.Name = name
+	}
+
+	files, err := s.tagFiles(ctx, dir, tag, rel)
+	if err != nil {
+		return nil, err
+	}
+	main := pack.MainFile(files, m.Type)
+	if main == nil {
+		return nil, fmt.Errorf("no content file in %s at %s", name, tag)
+	}
+
+	p := &Pack{
+		PackSummary: summaryFromMeta(m),
+		License:     m.License,
+		Content:     string(main.Content),
+		Files:       files,
+	}
+	if data, err := show(signing.SigFile); err == nil {
+		setSignature(p, data)
+	}
+	return p, nil
+}
+
+// tagFiles reads every file of a pack directory as it was at a git tag
+func (s *LocalStore) tagFiles(ctx context.Context, dir, tag, rel string) ([]pack.File, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "ls-tree", "-r", "--full-name", tag, "--", ".").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	type blob struct {
+		path, mode, sha string
+	}
+	var blobs []blob
+	packDirs := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// <mode> blob <sha>\t<path>
+		meta, full, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		path := strings.TrimPrefix(full, rel)
+		if !pack.Included(path) || fields[0] == "120000" {
+			continue
+		}
+		if d, f := filepath.Split(path); f == pack.MetaFile && d != "" {
+			packDirs[strings.TrimSuffix(d, "/")] = true
+		}
+		blobs = append(blobs, blob{path: path, mode: fields[0], sha: fields[2]})
+	}
+
+	var files []pack.File
+	for _, b := range blobs {
+		if pack.Nested(b.path, packDirs) {
+			continue
+		}
+		content, err := exec.CommandContext(ctx, "git", "-C", dir, "cat-file", "blob", b.sha).Output()
+		if err != nil {
+			return nil, err
+		}
+		mode := uint32(0644)
+		if b.mode == "100755" {
+			mode = 0755
+		}
+		files = append(files, pack.File{Path: b.path, Mode: mode, Content: content})
+	}
+
+	pack.SortFiles(files)
+	return files, nil
+}
+
+func readLocalPack(dir string, m *pack.Meta) (*Pack, error) {
+	content, _, err := pack.ReadContent(dir, m.Type)
+	if err != nil {
+		return nil, err
+	}
+	files, err := pack.ReadFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pack{
+		PackSummary: summaryFromMeta(m),
+		License:     m.License,
+		Content:     content,
+		Files:       files,
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, signing.SigFile)); err == nil {
+		setSignature(p, data)
+	}
+	return p, nil
+}
+
+// setSignature attaches a pack.sig to a pack. A malformed file is ignored,
+// which leaves the pack unsigned.
+func setSignature(p *Pack, data []byte) {
+	if sig, err := signing.ParseSignature(data); err == nil {
+		p.Signature = sig.Signature
+		p.KeyID = sig.KeyID
+	}
+}
+
+func summaryFromMeta(m *pack.Meta) PackSummary {
+	return PackSummary{
+		Name:        m.Name,
+		Version:     m.Version,
+		Type:        m.Type,
+		Description: m.Description,
+		Author:      m.Author,
+		Tags:        m.Tags,
+		SourceURL:   m.Repository,
+	}
+}
+
+// matchScore reports whether every query word matches the pack and how
+// strongly: name hits outrank tag hits, which outrank description hits.
+func matchScore(m *pack.Meta, words []string) (int, bool) {
+	name := strings.ToLower(m.Name)
+	desc := strings.ToLower(m.Description)
+
+	score := 0
+	for _, w := range words {
+		switch {
+		case strings.Contains(name, w):
+			score += 3
+		case hasTagContaining(m.Tags, w):
+			score += 2
+		case strings.Contains(desc, w), m.Type == w:
+			score += 1
+		default:
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+func hasTagContaining(tags []string, word string) bool {
+	for _, t := range tags {
+		if strings.Contains(strings.ToLower(t), word) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAllTags(tags, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, t := range tags {
+			if strings.EqualFold(t, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
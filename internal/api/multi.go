@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultRegistryName labels results from the default registry
+const DefaultRegistryName = "packs.sh"
+
+// Registry is one named backend in a MultiStore
+type Registry struct {
+	Name     string
+	Priority int // lower is checked first
+	Store    PackStore
+}
+
+// MultiStore resolves packs against an ordered list of registries. Get
+// returns the first hit in priority order; Search merges results from every
+// registry and drops duplicates, keeping the highest-priority copy.
+//
+// Only a registry that doesn't have a pack passes it on to the next one. A
+// registry that fails any other way stops the lookup, so a private registry
+// being down never installs a public pack with the same name instead.
+type MultiStore struct {
+	registries []Registry
+}
+
+// NewMultiStore creates a MultiStore. Registries are ordered by priority;
+// ties keep the order they were given in.
+func NewMultiStore(registries ...Registry) *MultiStore {
+	sorted := append([]Registry(nil), registries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return &MultiStore{registries: sorted}
+}
+
+// Registries returns the registries in resolution order
+func (m *MultiStore) Registries() []Registry {
+	return m.registries
+}
+
+// Store returns the backend of a named registry, or nil
+func (m *MultiStore) Store(name string) PackStore {
+	for _, r := range m.registries {
+		if r.Name == name {
+			return r.Store
+		}
+	}
+	return nil
+}
+
+// Search queries every registry concurrently and merges the results. Offset
+// and Limit apply to the merged results: each registry is asked for its
+// first Offset+Limit.
+func (m *MultiStore) Search(ctx context.Context, opts SearchOpts) ([]PackSummary, int32, error) {
+	offset := opts.Offset
+	each := opts
+	each.Offset = 0
+	if opts.Limit > 0 {
+		each.Limit = offset + opts.Limit
+	}
+
+	type result struct {
+		packs []PackSummary
+		total int32
+		err   error
+	}
+
+	results := make([]result, len(m.registries))
+	var wg sync.WaitGroup
+	for i, r := range m.registries {
+		wg.Add(1)
+		go func(i int, r Registry) {
+			defer wg.Done()
+			packs, total, err := r.Store.Search(ctx, each)
+			results[i] = result{packs: packs, total: total, err: err}
+		}(i, r)
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	var merged []PackSummary
+	var total int32
+	var firstErr error
+	failed := 0
+	for i, res := range results {
+		if res.err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", m.registries[i].Name, res.err)
+			}
+			continue
+		}
+		total += res.total
+		for _, p := range res.packs {
+			if seen[p.Name] {
+				total--
+				continue
+			}
+			seen[p.Name] = true
+			p.Registry = m.registries[i].Name
+			merged = append(merged, p)
+		}
+	}
+
+	// Only fail when no registry answered
+	if failed == len(m.registries) && firstErr != nil {
+		return nil, 0, firstErr
+	}
+
+	switch opts.Sort {
+	case "stars":
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].Stars > merged[j].Stars })
+	case "name":
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	}
+
+	if offset > 0 {
+		merged = merged[min(int(offset), len(merged)):]
+	}
+	if opts.Limit > 0 && len(merged) > int(opts.Limit) {
+		merged = merged[:opts.Limit]
+	}
+
+	return merged, total, nil
+}
+
+// Get returns the pack from the first registry that has it. Any error other
+// than not having it, like a network error, a hash mismatch or an unsafe
+// file path, stops the search rather than falling through to the next
+// registry.
+func (m *MultiStore) Get(ctx context.Context, name, version string) (*Pack, error) {
+	var errs []error
+	for _, r := range m.registries {
+		p, err := r.Store.Get(ctx, name, version)
+		if err != nil && !missing(err) {
+			return nil, fmt.Errorf("%s: %w", r.Name, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Name, err))
+			continue
+		}
+		p.Registry = r.Name
+		return p, nil
+	}
+	return nil, m.notFound(name, errs)
+}
+
+// ListVersions lists versions from the first registry that has the pack.
+// Registries that can't list versions are skipped too.
+func (m *MultiStore) ListVersions(ctx context.Context, name string) ([]string, error) {
+	var errs []error
+	for _, r := range m.registries {
+		versions, err := r.Store.ListVersions(ctx, name)
+		if err != nil && !missing(err) && !errors.Is(err, ErrNotSupported) {
+			return nil, fmt.Errorf("%s: %w", r.Name, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Name, err))
+			continue
+		}
+		if len(versions) > 0 {
+			return versions, nil
+		}
+	}
+	return nil, m.notFound(name, errs)
+}
+
+// Submit submits to the first registry that accepts submissions
+func (m *MultiStore) Submit(ctx context.Context, githubRef string) (string, string, string, error) {
+	for _, r := range m.registries {
+		name, version, message, err := r.Store.Submit(ctx, githubRef)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		return name, version, message, err
+	}
+	return "", "", "", ErrNotSupported
+}
+
+// missing reports whether a registry doesn't have a pack, which is the only
+// reason to ask the next one. Offline, a registry can't tell, so a pack it
+// hasn't cached stops the lookup like any other error.
+func missing(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func (m *MultiStore) notFound(name string, errs []error) error {
+	if len(errs) == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return fmt.Errorf("%w: %s\n%w", ErrNotFound, name, errors.Join(errs...))
+}
+
+// RegistryName derives a short label for a registry location: the host of a
+// URL, or the directory name of a local registry.
+func RegistryName(location string) string {
+	if location == "" || location == DefaultBaseURL {
+		return DefaultRegistryName
+	}
+	if u, err := url.Parse(location); err == nil && u.Host != "" {
+		return strings.TrimPrefix(u.Host, "www.")
+	}
+	return filepath.Base(strings.TrimPrefix(location, "file://"))
+}
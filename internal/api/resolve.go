@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var (
+	// ErrNoMatchingVersion is returned when no published version satisfies
+	// a constraint
+	ErrNoMatchingVersion = errors.New("no version matches")
+
+	// ErrInvalidConstraint is returned for constraints that don't parse
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+)
+
+// exactVersion matches a full version, which needs no resolution
+var exactVersion = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// ResolveVersion picks the version of a pack that a constraint selects from
+// the store's published versions: the highest one that satisfies it.
+//
+// Constraints are semver ranges ("^1.2", "~1.2.3", ">=1.0 <2"), an exact
+// version, or "latest"/"" for the highest stable version. Prereleases are
+// only selected when the constraint names one (">=2.0.0-beta") or when
+// prerelease is set. When the store can't list versions, "latest" resolves
+// to "" so the store picks.
+func ResolveVersion(ctx context.Context, store PackStore, name, constraint string, prerelease bool) (string, error) {
+	constraint = strings.TrimSpace(constraint)
+	if exactVersion.MatchString(constraint) {
+		return constraint, nil
+	}
+
+	versions, err := store.ListVersions(ctx, name)
+	if err != nil || len(versions) == 0 {
+		if IsLatest(constraint) {
+			return "", nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return "", err
+	}
+
+	v, err := MatchVersion(versions, constraint, prerelease)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return v, nil
+}
+
+// MatchVersion returns the highest version satisfying constraint. Versions
+// that aren't semver are ignored.
+func MatchVersion(versions []string, constraint string, prerelease bool) (string, error) {
+	constraint = strings.TrimSpace(constraint)
+	if IsLatest(constraint) {
+		constraint = "*"
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidConstraint, constraint, err)
+	}
+
+	var best *semver.Version
+	var bestRaw string
+	for _, raw := range versions {
+		v, err := semver.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+		if !matches(c, v, prerelease) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, bestRaw = v, raw
+		}
+	}
+
+	if best == nil {
+		return "", fmt.Errorf("%w %s (available: %s)", ErrNoMatchingVersion, constraint, strings.Join(SortVersions(versions), ", "))
+	}
+	return bestRaw, nil
+}
+
+// matches checks v against c. With prerelease set, a prerelease also
+// matches when its release version does, so "^1.2" accepts "1.3.0-rc.1".
+func matches(c *semver.Constraints, v *semver.Version, prerelease bool) bool {
+	if c.Check(v) {
+		return true
+	}
+	if !prerelease || v.Prerelease() == "" {
+		return false
+	}
+	release, err := v.SetPrerelease("")
+	return err == nil && c.Check(&release)
+}
+
+// IsResolveError reports whether err means the constraint itself can't be
+// satisfied, as opposed to the pack or registry being unavailable
+func IsResolveError(err error) bool {
+	return errors.Is(err, ErrNoMatchingVersion) || errors.Is(err, ErrInvalidConstraint)
+}
+
+// IsLatest reports whether a constraint means "the latest version"
+func IsLatest(constraint string) bool {
+	return constraint == "" || constraint == "latest" || constraint == "*"
+}
+
+// IsExactVersion reports whether a constraint pins a single version
+func IsExactVersion(constraint string) bool {
+	return exactVersion.MatchString(strings.TrimSpace(constraint))
+}
+
+// SortVersions sorts versions in semver order, oldest first. Versions that
+// aren't semver sort before the rest, alphabetically.
+func SortVersions(versions []string) []string {
+	sorted := append([]string(nil), versions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		vi, erri := semver.NewVersion(sorted[i])
+		vj, errj := semver.NewVersion(sorted[j])
+		switch {
+		case erri != nil && errj != nil:
+			return sorted[i] < sorted[j]
+		case erri != nil:
+			return true
+		case errj != nil:
+			return false
+		default:
+			return vi.LessThan(vj)
+		}
+	})
+	return sorted
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var published = []string{"0.9.0", "1.0.0", "1.2.0", "1.2.5", "1.3.0-rc.1", "1.3.0", "2.0.0-beta.1", "not-semver"}
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		prerelease bool
+		want       string
+		err        error
+	}{
+		{"", false, "1.3.0", nil},
+		{"latest", false, "1.3.0", nil},
+		{"*", false, "1.3.0", nil},
+		{"latest", true, "2.0.0-beta.1", nil},
+		{"^1.2", false, "1.3.0", nil},
+		{"~1.2", false, "1.2.5", nil},
+		{"~1.2.0", false, "1.2.5", nil},
+		{">=1.0 <1.2", false, "1.0.0", nil},
+		{"1.2.0", false, "1.2.0", nil},
+		{"^0.9", false, "0.9.0", nil},
+		{">=2.0.0-beta", false, "2.0.0-beta.1", nil},
+		{"^2", false, "", ErrNoMatchingVersion},
+		{"^2", true, "2.0.0-beta.1", nil},
+		{"^3", true, "", ErrNoMatchingVersion},
+		{"not a range", false, "", ErrInvalidConstraint},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			got, err := MatchVersion(published, tt.constraint, tt.prerelease)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("MatchVersion(%q) = %q, %v; want %v", tt.constraint, got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("MatchVersion(%q) = %q, %v; want %q", tt.constraint, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// versionStore is a PackStore that only lists versions
+type versionStore struct {
+	PackStore
+	versions []string
+	err      error
+	listed   bool
+}
+
+func (s *versionStore) ListVersions(ctx context.Context, name string) ([]string, error) {
+	s.listed = true
+	return s.versions, s.err
+}
+
+func TestResolveVersion(t *testing.T) {
+	down := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		store      *versionStore
+		constraint string
+		want       string
+		err        error
+		noList     bool
+	}{
+		{"range", &versionStore{versions: published}, "^1.0", "1.3.0", nil, false},
+		{"exact skips listing", &versionStore{err: down}, "1.2.0", "1.2.0", nil, true},
+		{"exact with v", &versionStore{err: down}, "v1.2.0", "v1.2.0", nil, true},
+		{"latest without listing", &versionStore{err: ErrNotSupported}, "latest", "", nil, false},
+		{"latest with no versions", &versionStore{}, "", "", nil, false},
+		{"range when listing fails", &versionStore{err: down}, "^1.0", "", down, false},
+		{"range with no versions", &versionStore{}, "^1.0", "", ErrNotFound, false},
+		{"range with no match", &versionStore{versions: published}, "^5", "", ErrNoMatchingVersion, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveVersion(context.Background(), tt.store, "hello", tt.constraint, false)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ResolveVersion(%q) = %q, %v; want %v", tt.constraint, got, err, tt.err)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("ResolveVersion(%q) = %q, %v; want %q", tt.constraint, got, err, tt.want)
+			}
+			if tt.noList && tt.store.listed {
+				t.Fatalf("ResolveVersion(%q) listed versions for an exact version", tt.constraint)
+			}
+		})
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	got := SortVersions([]string{"1.10.0", "1.2.0", "b", "1.2.0-rc.1", "a", "0.1.0"})
+	want := []string{"a", "b", "0.1.0", "1.2.0-rc.1", "1.2.0", "1.10.0"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("SortVersions = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a pack or version doesn't exist in a store
+	ErrNotFound = errors.New("pack not found")
+
+	// ErrNotSupported is returned by stores that don't implement an operation
+	ErrNotSupported = errors.New("operation not supported by this registry")
+)
+
+// PackStore is a pack storage backend. The CLI and TUI only talk to this
+// interface, so commands work the same against packs.sh, a self-hosted
+// registry, or anything else that implements it.
+type PackStore interface {
+	// Search searches for packs matching opts and returns the total hit count
+	Search(ctx context.Context, opts SearchOpts) ([]PackSummary, int32, error)
+
+	// Get fetches a pack by name and optional version (empty = latest)
+	Get(ctx context.Context, name, version string) (*Pack, error)
+
+	// ListVersions lists all published versions of a pack
+	ListVersions(ctx context.Context, name string) ([]string, error)
+
+	// Submit submits a GitHub pack for indexing
+	Submit(ctx context.Context, githubRef string) (name, version, message string, err error)
+}
+
+// TelemetrySink is implemented by stores that accept usage telemetry
+type TelemetrySink interface {
+	Telemetry(ctx context.Context, pack, source, version, cliVersion, os, arch string)
+}
+
+var (
+	_ PackStore     = (*Client)(nil)
+	_ PackStore     = (*LocalStore)(nil)
+	_ PackStore     = (*MultiStore)(nil)
+	_ PackStore     = (*CachedStore)(nil)
+	_ TelemetrySink = (*Client)(nil)
+	_ TelemetrySink = (*CachedStore)(nil)
+)
+
+// Open returns the PackStore for a registry location. An http(s) URL is a
+// Connect registry; a file:// URL or filesystem path is a LocalStore. An
+// empty location means the default registry.
+func Open(location, authToken string) PackStore {
+	switch {
+	case location == "":
+		return NewWithAuth(authToken)
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return NewWithURL(location, authToken)
+	case strings.HasPrefix(location, "file://"):
+		return NewLocalStore(strings.TrimPrefix(location, "file://"))
+	default:
+		return NewLocalStore(expandHome(location))
+	}
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return home + path[1:]
+	}
+	return path
+}
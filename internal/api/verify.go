@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// ErrHashMismatch is returned when fetched content doesn't match the hash
+// the registry declared for it
+var ErrHashMismatch = errors.New("content hash mismatch")
+
+// VerifyContent checks a pack's content against its declared SHA-256, given
+// as "sha256:<hex>" or bare hex, and every file against its own hash. Packs
+// that declare no hash pass.
+func VerifyContent(p *Pack) error {
+	if err := pack.Verify(p.Files); err != nil {
+		if errors.Is(err, pack.ErrFileHash) {
+			return fmt.Errorf("%w for %s@%s: %v", ErrHashMismatch, p.Name, p.Version, err)
+		}
+		return fmt.Errorf("%s@%s: %w", p.Name, p.Version, err)
+	}
+
+	declared := strings.ToLower(strings.TrimSpace(p.ContentHash))
+	if declared == "" {
+		return nil
+	}
+	if algo, _, ok := strings.Cut(declared, ":"); ok && algo != "sha256" {
+		return fmt.Errorf("%s@%s: unsupported content hash algorithm %q", p.Name, p.Version, algo)
+	}
+	if !strings.HasPrefix(declared, "sha256:") {
+		declared = "sha256:" + declared
+	}
+
+	if got := pack.Hash([]byte(p.Content)); got != declared {
+		return fmt.Errorf("%w for %s@%s\n  expected: %s\n  got:      %s", ErrHashMismatch, p.Name, p.Version, declared, got)
+	}
+	return nil
+}
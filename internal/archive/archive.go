@@ -0,0 +1,366 @@
+// Package archive reads packs delivered as one compressed archive: a tar.gz
+// or zip from a registry, GitHub's archive endpoint, a URL or a local file.
+//
+// Extraction never touches the filesystem. Entries are read into pack files
+// in memory, so a hostile archive can't write outside the install directory:
+// paths must be relative and clean, symlinks pointing outside the pack are
+// rejected (others are skipped, like in pack directories), and file count
+// and sizes are capped.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/signing"
+)
+
+// Archive formats
+const (
+	FormatTarGz = "tar.gz"
+	FormatZip   = "zip"
+)
+
+// MaxSize caps the size of a downloaded archive
+const MaxSize = 100 << 20
+
+var (
+	// ErrFormat is returned for data that is neither a tar.gz nor a zip
+	ErrFormat = errors.New("not a tar.gz or zip archive")
+
+	// ErrUnsafe is returned for entries that would escape the pack
+	// directory. It is pack.ErrUnsafePath, so installs refuse it the same way.
+	ErrUnsafe = pack.ErrUnsafePath
+
+	// ErrTooLarge is returned when an archive exceeds its limits
+	ErrTooLarge = errors.New("archive too large")
+)
+
+// Limits caps what an archive may contain. Zero values are unlimited.
+type Limits struct {
+	MaxFiles     int
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// DefaultLimits are generous for a pack and small enough to stop
+// decompression bombs
+var DefaultLimits = Limits{
+	MaxFiles:     1000,
+	MaxFileSize:  10 << 20,
+	MaxTotalSize: 50 << 20,
+}
+
+// Options controls extraction
+type Options struct {
+	// Dir extracts only the files under this slash-separated directory,
+	// relative to it, e.g. a pack inside a repository archive
+	Dir string
+
+	// StripTop drops the first path element of every entry, like the
+	// <repo>-<sha>/ directory GitHub wraps archives in. Without it, a single
+	// top-level directory wrapping everything is dropped automatically.
+	StripTop bool
+
+	Limits Limits
+}
+
+// Contents is what an archive holds for a pack
+type Contents struct {
+	Files     []pack.File // sorted by path
+	Signature []byte      // pack.sig, when the archive carries one
+}
+
+// Detect returns the format of archive data from its magic bytes, or ""
+func Detect(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return FormatTarGz
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return FormatZip
+	default:
+		return ""
+	}
+}
+
+// IsArchiveName reports whether a path or URL names an archive by extension
+func IsArchiveName(name string) bool {
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, "?#"); i != -1 {
+		name = name[:i]
+	}
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAll reads an archive from r, failing past MaxSize
+func ReadAll(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("%w: over %d MB", ErrTooLarge, MaxSize>>20)
+	}
+	return data, nil
+}
+
+// Extract reads the pack files out of archive data
+func Extract(data []byte, opts Options) (*Contents, error) {
+	x := &extractor{opts: opts, seen: map[string]bool{}}
+
+	var err error
+	switch Detect(data) {
+	case FormatTarGz:
+		err = x.tarGz(data)
+	case FormatZip:
+		err = x.zip(data)
+	default:
+		return nil, ErrFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Dir == "" && !opts.StripTop {
+		x.stripCommonDir()
+	}
+	return x.contents(), nil
+}
+
+// extractor collects entries across both formats
+type extractor struct {
+	opts  Options
+	files []pack.File
+	seen  map[string]bool
+	total int64
+}
+
+func (x *extractor) tarGz(data []byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			if err := x.file(hdr.Name, uint32(hdr.Mode), hdr.Size, tr); err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			if err := x.link(hdr.Name, hdr.Linkname, hdr.Typeflag == tar.TypeLink); err != nil {
+				return err
+			}
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			// Directories are implied by file paths; GitHub adds a global
+			// header with the commit
+		default:
+			// Devices, fifos and the like have no place in a pack
+			if _, err := x.path(hdr.Name); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (x *extractor) zip(data []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			continue
+		case mode&fs.ModeSymlink != 0:
+			target, err := readZipFile(f, 4096)
+			if err != nil {
+				return err
+			}
+			if err := x.link(f.Name, string(target), false); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", f.Name, err)
+			}
+			err = x.file(f.Name, uint32(mode.Perm()), int64(f.UncompressedSize64), rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			if _, err := x.path(f.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// path validates an entry name and returns it relative to the pack root,
+// or "" when the entry lies outside opts.Dir
+func (x *extractor) path(name string) (string, error) {
+	name = strings.TrimPrefix(strings.TrimSuffix(name, "/"), "./")
+	if !pack.ValidPath(name) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafe, name)
+	}
+
+	if x.opts.StripTop {
+		_, rest, ok := strings.Cut(name, "/")
+		if !ok {
+			return "", nil
+		}
+		name = rest
+	}
+	if x.opts.Dir != "" {
+		rest, ok := strings.CutPrefix(name, x.opts.Dir+"/")
+		if !ok {
+			return "", nil
+		}
+		name = rest
+	}
+	return name, nil
+}
+
+func (x *extractor) file(name string, mode uint32, size int64, r io.Reader) error {
+	rel, err := x.path(name)
+	if err != nil || rel == "" {
+		return err
+	}
+	if x.seen[rel] {
+		return fmt.Errorf("%w: %q appears twice", ErrUnsafe, rel)
+	}
+	x.seen[rel] = true
+
+	limits := x.opts.Limits
+	if limits.MaxFiles > 0 && len(x.files) >= limits.MaxFiles {
+		return fmt.Errorf("%w: more than %d files", ErrTooLarge, limits.MaxFiles)
+	}
+	if limits.MaxFileSize > 0 && size > limits.MaxFileSize {
+		return fmt.Errorf("%w: %s is %d bytes, the limit is %d", ErrTooLarge, rel, size, limits.MaxFileSize)
+	}
+
+	// Headers can lie about sizes, so limit the read itself too
+	max := limits.MaxFileSize
+	if max <= 0 {
+		max = MaxSize
+	}
+	content, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", rel, err)
+	}
+	if int64(len(content)) > max {
+		return fmt.Errorf("%w: %s is over %d bytes", ErrTooLarge, rel, max)
+	}
+
+	x.total += int64(len(content))
+	if limits.MaxTotalSize > 0 && x.total > limits.MaxTotalSize {
+		return fmt.Errorf("%w: over %d bytes extracted", ErrTooLarge, limits.MaxTotalSize)
+	}
+
+	x.files = append(x.files, pack.File{Path: rel, Mode: pack.NormalizeMode(mode), Content: content})
+	return nil
+}
+
+// link rejects symlinks and hard links that point outside the pack. Links
+// inside it are skipped: packs never install links.
+func (x *extractor) link(name, target string, hard bool) error {
+	rel, err := x.path(name)
+	if err != nil || rel == "" {
+		return err
+	}
+
+	// Hard link targets are archive paths; symlinks are relative to the link
+	resolved := target
+	if !hard {
+		resolved = path.Join(path.Dir(name), target)
+	}
+	if path.IsAbs(target) || !pack.ValidPath(resolved) {
+		return fmt.Errorf("%w: %s links outside the pack to %q", ErrUnsafe, rel, target)
+	}
+	if inside, err := x.path(resolved); err != nil || inside == "" {
+		return fmt.Errorf("%w: %s links outside the pack to %q", ErrUnsafe, rel, target)
+	}
+	return nil
+}
+
+// stripCommonDir drops a single top-level directory that wraps every file,
+// as in archives made with `tar czf pack.tgz my-pack/`
+func (x *extractor) stripCommonDir() {
+	if len(x.files) == 0 {
+		return
+	}
+	top, _, ok := strings.Cut(x.files[0].Path, "/")
+	if !ok {
+		return
+	}
+	for _, f := range x.files {
+		if !strings.HasPrefix(f.Path, top+"/") {
+			return
+		}
+	}
+	for i := range x.files {
+		x.files[i].Path = strings.TrimPrefix(x.files[i].Path, top+"/")
+	}
+}
+
+// contents applies the pack file rules: dotfiles, pack.sig and nested packs
+// are not part of the file set
+func (x *extractor) contents() *Contents {
+	packDirs := map[string]bool{}
+	for _, f := range x.files {
+		if path.Base(f.Path) == pack.MetaFile && path.Dir(f.Path) != "." {
+			packDirs[path.Dir(f.Path)] = true
+		}
+	}
+
+	c := &Contents{}
+	for _, f := range x.files {
+		switch {
+		case f.Path == signing.SigFile:
+			c.Signature = f.Content
+		case !pack.Included(f.Path), pack.Nested(f.Path, packDirs):
+		default:
+			c.Files = append(c.Files, f)
+		}
+	}
+	pack.SortFiles(c.Files)
+	return c
+}
+
+func readZipFile(f *zip.File, max int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, max))
+}
@@ -0,0 +1,270 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+// entry is a file, link or directory to put in a test archive
+type entry struct {
+	name   string
+	body   string
+	mode   int64
+	link   string // symlink target
+	hard   string // hard link target
+	device bool
+}
+
+func file(name, body string) entry { return entry{name: name, body: body, mode: 0644} }
+
+func tarGz(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: e.mode, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		switch {
+		case e.link != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.link, 0
+		case e.hard != "":
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeLink, e.hard, 0
+		case e.device:
+			hdr.Typeflag, hdr.Size = tar.TypeChar, 0
+		case strings.HasSuffix(e.name, "/"):
+			hdr.Typeflag, hdr.Size = tar.TypeDir, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipData(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		switch {
+		case e.link != "":
+			hdr.SetMode(fs.ModeSymlink | 0777)
+			body = e.link
+		case strings.HasSuffix(e.name, "/"):
+			hdr.SetMode(fs.ModeDir | 0755)
+		default:
+			hdr.SetMode(fs.FileMode(e.mode))
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		opts    Options
+		want    []string
+		sig     string
+	}{
+		{
+			name:    "flat",
+			entries: []entry{file("SKILL.md", "hi"), file("pack.yaml", "name: x")},
+			want:    []string{"SKILL.md", "pack.yaml"},
+		},
+		{
+			name:    "wrapping directory is dropped",
+			entries: []entry{{name: "hello/", mode: 0755}, file("hello/SKILL.md", "hi"), file("./hello/ref/a.md", "a")},
+			want:    []string{"SKILL.md", "ref/a.md"},
+		},
+		{
+			name:    "strip top",
+			entries: []entry{file("repo-abc/SKILL.md", "hi"), file("top.md", "skipped")},
+			opts:    Options{StripTop: true},
+			want:    []string{"SKILL.md"},
+		},
+		{
+			name:    "dir",
+			entries: []entry{file("repo-abc/packs/hello/SKILL.md", "hi"), file("repo-abc/packs/other/SKILL.md", "no")},
+			opts:    Options{StripTop: true, Dir: "packs/hello"},
+			want:    []string{"SKILL.md"},
+		},
+		{
+			name:    "signature, dotfiles and nested packs",
+			entries: []entry{file("SKILL.md", "hi"), file("pack.sig", "sig"), file(".DS_Store", "x"), file("v1/pack.yaml", "name: x"), file("v1/SKILL.md", "old")},
+			want:    []string{"SKILL.md"},
+			sig:     "sig",
+		},
+		{
+			name:    "links inside the pack are skipped",
+			entries: []entry{file("SKILL.md", "hi"), {name: "ref/alias.md", link: "../SKILL.md"}},
+			want:    []string{"SKILL.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		for format, build := range map[string]func(*testing.T, ...entry) []byte{FormatTarGz: tarGz, FormatZip: zipData} {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				data := build(t, tt.entries...)
+				if got := Detect(data); got != format {
+					t.Fatalf("Detect = %q, want %q", got, format)
+				}
+				c, err := Extract(data, tt.opts)
+				if err != nil {
+					t.Fatalf("Extract: %v", err)
+				}
+				var got []string
+				for _, f := range c.Files {
+					got = append(got, f.Path)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Fatalf("files = %v, want %v", got, tt.want)
+				}
+				if string(c.Signature) != tt.sig {
+					t.Fatalf("signature = %q, want %q", c.Signature, tt.sig)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractUnsafe(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		tarOnly bool
+	}{
+		{name: "parent dir", entries: []entry{file("../evil.sh", "x")}},
+		{name: "nested parent dir", entries: []entry{file("hello/../../evil.sh", "x")}},
+		{name: "dot slash parent", entries: []entry{file("./../evil.sh", "x")}},
+		{name: "absolute", entries: []entry{file("/etc/cron.d/evil", "x")}},
+		{name: "backslash", entries: []entry{file("..\\evil.sh", "x")}},
+		{name: "unclean", entries: []entry{file("a//b.md", "x")}},
+		{name: "symlink out", entries: []entry{file("SKILL.md", "hi"), {name: "key", link: "../../.ssh/id_ed25519"}}},
+		{name: "absolute symlink", entries: []entry{file("SKILL.md", "hi"), {name: "passwd", link: "/etc/passwd"}}},
+		{name: "hard link out", entries: []entry{file("SKILL.md", "hi"), {name: "key", hard: "../.ssh/id_ed25519"}}, tarOnly: true},
+		{name: "device with bad path", entries: []entry{{name: "../dev", device: true}}, tarOnly: true},
+		{name: "duplicate", entries: []entry{file("SKILL.md", "a"), file("SKILL.md", "b")}},
+	}
+
+	for _, tt := range tests {
+		builds := map[string]func(*testing.T, ...entry) []byte{FormatTarGz: tarGz}
+		if !tt.tarOnly {
+			builds[FormatZip] = zipData
+		}
+		for format, build := range builds {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				_, err := Extract(build(t, tt.entries...), Options{})
+				if !errors.Is(err, ErrUnsafe) {
+					t.Fatalf("Extract = %v, want %v", err, ErrUnsafe)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractLimits(t *testing.T) {
+	big := strings.Repeat("x", 2048)
+	tests := []struct {
+		name    string
+		entries []entry
+		limits  Limits
+	}{
+		{"too many files", []entry{file("a.md", "a"), file("b.md", "b"), file("c.md", "c")}, Limits{MaxFiles: 2}},
+		{"file too big", []entry{file("a.md", big)}, Limits{MaxFileSize: 1024}},
+		{"total too big", []entry{file("a.md", big), file("b.md", big)}, Limits{MaxFileSize: 4096, MaxTotalSize: 3000}},
+	}
+
+	for _, tt := range tests {
+		for format, build := range map[string]func(*testing.T, ...entry) []byte{FormatTarGz: tarGz, FormatZip: zipData} {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				_, err := Extract(build(t, tt.entries...), Options{Limits: tt.limits})
+				if !errors.Is(err, ErrTooLarge) {
+					t.Fatalf("Extract = %v, want %v", err, ErrTooLarge)
+				}
+			})
+		}
+	}
+
+	// The same archives fit without limits
+	for _, tt := range tests {
+		if _, err := Extract(tarGz(t, tt.entries...), Options{}); err != nil {
+			t.Fatalf("%s: Extract without limits: %v", tt.name, err)
+		}
+	}
+}
+
+func TestExtractFormat(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("name: hello\n"), {0x1f, 0x8b, 0x00}, []byte("PK\x03\x04garbage")} {
+		if _, err := Extract(data, Options{}); !errors.Is(err, ErrFormat) {
+			t.Fatalf("Extract(%q) = %v, want %v", data, err, ErrFormat)
+		}
+	}
+}
+
+// zeros is an endless reader of zero bytes
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestReadAll(t *testing.T) {
+	data, err := ReadAll(io.LimitReader(zeros{}, 1024))
+	if err != nil || len(data) != 1024 {
+		t.Fatalf("ReadAll = %d bytes, %v; want 1024 bytes", len(data), err)
+	}
+	if testing.Short() {
+		t.Skip("reads over MaxSize")
+	}
+	if _, err := ReadAll(zeros{}); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("ReadAll of an endless reader = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+func TestIsArchiveName(t *testing.T) {
+	tests := map[string]bool{
+		"pack.tar.gz":                   true,
+		"pack.TGZ":                      true,
+		"https://x.dev/pack.zip?sig=ab": true,
+		"pack.tar":                      false,
+		"./dir":                         false,
+		"https://x.dev/zip":             false,
+	}
+	for name, want := range tests {
+		if got := IsArchiveName(name); got != want {
+			t.Errorf("IsArchiveName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package cache is the local cache of registry data under ~/.packs/cache.
+//
+// Layout:
+//
+//	index/<kind>/<sha256(key)>.json   entries: search results, pack metadata
+//	content/<sha256>                  pack content, addressed by its hash
+//
+// Entries expire after the TTL but are kept until pruned, so offline mode
+// can still serve them.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tunajam/packs/internal/atomicfile"
+)
+
+// Entry kinds
+const (
+	KindSearch   = "search"
+	KindPack     = "pack"
+	KindVersions = "versions"
+)
+
+var (
+	// ErrMiss is returned when a key isn't cached
+	ErrMiss = errors.New("not in cache")
+
+	// ErrCorrupt is returned when cached content no longer matches its hash
+	ErrCorrupt = errors.New("content hash mismatch")
+)
+
+// Cache is a file-backed cache with a TTL and a size limit. It's safe for
+// concurrent use; share one per process so writes prune it once.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64 // bytes on disk as of the last walk plus writes since; -1 before the first
+}
+
+// Entry is one cached value
+type Entry struct {
+	Kind        string          `json:"kind"`
+	Key         string          `json:"key"`
+	Name        string          `json:"name,omitempty"`
+	Version     string          `json:"version,omitempty"`
+	ContentHash string          `json:"content_hash,omitempty"` // hex SHA-256 of the content blob
+	Blobs       []string        `json:"blobs,omitempty"`        // other content blobs it references
+	StoredAt    time.Time       `json:"stored_at"`
+	Data        json.RawMessage `json:"data"`
+
+	path string
+	size int64
+}
+
+// Path returns the entry's index file
+func (e *Entry) Path() string {
+	return e.path
+}
+
+// Size returns the size of the index file on disk
+func (e *Entry) Size() int64 {
+	return e.size
+}
+
+// New creates a cache in dir. A zero ttl never expires; a zero maxSize is
+// unlimited.
+func New(dir string, ttl time.Duration, maxSize int64) *Cache {
+	return &Cache{dir: dir, ttl: ttl, maxSize: maxSize, size: -1}
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Fresh reports whether an entry is within the TTL
+func (c *Cache) Fresh(e *Entry) bool {
+	return c.ttl == 0 || time.Since(e.StoredAt) < c.ttl
+}
+
+// Get loads an entry and decodes its data into v
+func (c *Cache) Get(kind, key string, v any) (*Entry, error) {
+	path := c.entryPath(kind, key)
+	e, err := readEntry(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		if err := json.Unmarshal(e.Data, v); err != nil {
+			return nil, fmt.Errorf("corrupt cache entry %s: %w", path, err)
+		}
+	}
+	return e, nil
+}
+
+// Put stores v under kind/key. The entry template carries optional pack
+// metadata (name, version, content hash) for listing.
+func (c *Cache) Put(kind, key string, v any, meta Entry) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	meta.Kind = kind
+	meta.Key = key
+	meta.StoredAt = time.Now()
+	meta.Data = data
+
+	out, err := json.MarshalIndent(&meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := c.entryPath(kind, key)
+	if err := atomicfile.Write(path, out, 0644); err != nil {
+		return err
+	}
+	return c.grew(int64(len(out)))
+}
+
+// grew counts bytes just written, and prunes the cache to maxSize once the
+// count passes it. The cache is walked for its size once, not on every
+// write.
+func (c *Cache) grew(n int64) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size < 0 {
+		c.size = c.Size()
+	} else {
+		c.size += n
+	}
+	if c.size <= c.maxSize {
+		return nil
+	}
+	_, err := c.prune(0, c.maxSize)
+	c.size = c.Size()
+	return err
+}
+
+// PutContent stores content by its SHA-256 and returns the hex hash
+func (c *Cache) PutContent(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+
+	path := c.ContentPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := atomicfile.Write(path, content, 0644); err != nil {
+		return "", err
+	}
+	// The entry referencing the blob counts it when it's written
+	c.mu.Lock()
+	if c.size >= 0 {
+		c.size += int64(len(content))
+	}
+	c.mu.Unlock()
+	return hash, nil
+}
+
+// Content reads a content blob by hash
+func (c *Cache) Content(hash string) ([]byte, error) {
+	data, err := os.ReadFile(c.ContentPath(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrMiss
+	}
+	return data, err
+}
+
+// ContentPath returns the blob path for a content hash
+func (c *Cache) ContentPath(hash string) string {
+	return filepath.Join(c.dir, "content", hash)
+}
+
+// Hashes returns every content blob an entry references
+func (e *Entry) Hashes() []string {
+	var hashes []string
+	if e.ContentHash != "" {
+		hashes = append(hashes, e.ContentHash)
+	}
+	return append(hashes, e.Blobs...)
+}
+
+// Verify recomputes the SHA-256 of an entry's content blobs and compares
+// them with their names. Entries without content always verify.
+func (c *Cache) Verify(e *Entry) error {
+	for _, hash := range e.Hashes() {
+		data, err := c.Content(hash)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); got != hash {
+			return fmt.Errorf("%w: %s is %s", ErrCorrupt, hash[:12], got[:12])
+		}
+	}
+	return nil
+}
+
+// Entries lists cached entries of a kind ("" for all), newest first
+func (c *Cache) Entries(kind string) ([]*Entry, error) {
+	pattern := filepath.Join(c.dir, "index", "*", "*.json")
+	if kind != "" {
+		pattern = filepath.Join(c.dir, "index", kind, "*.json")
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, path := range paths {
+		e, err := readEntry(path)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StoredAt.After(entries[j].StoredAt)
+	})
+	return entries, nil
+}
+
+// Remove deletes an entry. Its content blob is left for Prune.
+func (c *Cache) Remove(e *Entry) error {
+	return os.Remove(e.path)
+}
+
+// Clear deletes the whole cache
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+// Size returns the total size of the cache in bytes
+func (c *Cache) Size() int64 {
+	var total int64
+	filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// Prune removes entries older than olderThan (0 = no age limit), then the
+// oldest entries until the cache fits in maxSize (0 = no limit), then
+// content blobs no entry references. It returns the bytes freed.
+func (c *Cache) Prune(olderThan time.Duration, maxSize int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	freed, err := c.prune(olderThan, maxSize)
+	c.size = -1
+	return freed, err
+}
+
+// blobGrace is how long a blob no entry references yet is kept, since its
+// entry is written after it
+const blobGrace = time.Minute
+
+func (c *Cache) prune(olderThan time.Duration, maxSize int64) (int64, error) {
+	entries, err := c.Entries("")
+	if err != nil {
+		return 0, err
+	}
+
+	before := c.Size()
+
+	var kept []*Entry
+	for _, e := range entries {
+		if olderThan > 0 && time.Since(e.StoredAt) > olderThan {
+			os.Remove(e.path)
+			continue
+		}
+		kept = append(kept, e)
+	}
+
+	if maxSize > 0 {
+		size := c.Size()
+		// Entries are newest first, so drop from the end
+		for size > maxSize && len(kept) > 0 {
+			oldest := kept[len(kept)-1]
+			kept = kept[:len(kept)-1]
+			os.Remove(oldest.path)
+			size -= oldest.size
+			for _, hash := range oldest.Hashes() {
+				if referenced(kept, hash) {
+					continue
+				}
+				if info, err := os.Stat(c.ContentPath(hash)); err == nil {
+					os.Remove(c.ContentPath(hash))
+					size -= info.Size()
+				}
+			}
+		}
+	}
+
+	blobs, _ := filepath.Glob(filepath.Join(c.dir, "content", "*"))
+	for _, blob := range blobs {
+		if referenced(kept, filepath.Base(blob)) {
+			continue
+		}
+		if info, err := os.Stat(blob); err == nil && time.Since(info.ModTime()) < blobGrace {
+			continue
+		}
+		os.Remove(blob)
+	}
+
+	return before - c.Size(), nil
+}
+
+func referenced(entries []*Entry, hash string) bool {
+	for _, e := range entries {
+		for _, h := range e.Hashes() {
+			if h == hash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Cache) entryPath(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, "index", kind, hex.EncodeToString(sum[:])+".json")
+}
+
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	e.path = path
+	e.size = int64(len(data))
+	return &e, nil
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPutPrunesPastMaxSize(t *testing.T) {
+	c := New(t.TempDir(), 0, 4096)
+	value := strings.Repeat("x", 1000)
+
+	for i := range 8 {
+		if err := c.Put(KindSearch, fmt.Sprint(i), value, Entry{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if size := c.Size(); size > 4096 {
+		t.Errorf("Size() = %d, want at most 4096", size)
+	}
+	if _, err := c.Get(KindSearch, "7", nil); err != nil {
+		t.Errorf("newest entry pruned: %v", err)
+	}
+	if _, err := c.Get(KindSearch, "0", nil); err != ErrMiss {
+		t.Errorf("oldest entry kept: err = %v, want ErrMiss", err)
+	}
+}
+
+func TestPutUnderMaxSizeKeepsEverything(t *testing.T) {
+	c := New(t.TempDir(), 0, 1<<20)
+	for i := range 8 {
+		if err := c.Put(KindSearch, fmt.Sprint(i), "value", Entry{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := c.Entries("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 8 {
+		t.Errorf("%d entries, want 8", len(entries))
+	}
+}
+
+func TestPruneKeepsFreshUnreferencedBlobs(t *testing.T) {
+	c := New(t.TempDir(), 0, 0)
+	hash, err := c.PutContent([]byte("content whose entry isn't written yet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Prune(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Content(hash); err != nil {
+		t.Errorf("fresh blob pruned: %v", err)
+	}
+}
+
+func TestConcurrentPuts(t *testing.T) {
+	c := New(t.TempDir(), 0, 8192)
+	value := strings.Repeat("x", 500)
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 10 {
+				hash, err := c.PutContent([]byte(fmt.Sprint(g, i)))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err := c.Put(KindPack, fmt.Sprint(g, "-", i), value, Entry{ContentHash: hash}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if size := c.Size(); size > 8192 {
+		t.Errorf("Size() = %d, want at most 8192", size)
+	}
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/archive"
+	"github.com/tunajam/packs/internal/manifest"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/packref"
+	"github.com/tunajam/packs/internal/signing"
+)
+
+// fetchFromArchive installs a pack from a local archive or a URL. The name,
+// version and type come from the archive's pack.yaml.
+func fetchFromArchive(ctx context.Context, r packref.PackRef) (*fetchedPack, error) {
+	data, err := readArchive(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := archive.Extract(data, archive.Options{Limits: archive.DefaultLimits})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r, err)
+	}
+	return fetchedFromFiles(r, r.BaseName(), contents.Files, contents.Signature)
+}
+
+// fetchedFromFiles describes a pack fetched as a set of files, from an
+// archive or a directory. Its version, type and name come from its
+// pack.yaml, the name from name when it doesn't have one, and the pack.sig
+// next to it is checked.
+func fetchedFromFiles(r packref.PackRef, name string, files []packfmt.File, signature []byte) (*fetchedPack, error) {
+	meta := &packfmt.Meta{}
+	var err error
+	for _, f := range files {
+		if f.Path == packfmt.MetaFile {
+			if meta, err = packfmt.ParseMeta(f.Content); err != nil {
+				return nil, fmt.Errorf("%s: %w", r, err)
+			}
+		}
+	}
+	if meta.Name == "" {
+		meta.Name = name
+	}
+	if packfmt.MainFile(files, meta.Type) == nil {
+		return nil, fmt.Errorf("pack not found in %s\nTried: SKILL.md, CONTEXT.md, PROMPT.md", r)
+	}
+	if meta.Type == "" {
+		meta.Type = filesPackType(files)
+	}
+
+	digest := packfmt.Digest(files)
+	subject := signing.Subject{
+		Name:        meta.Name,
+		Version:     meta.Version,
+		ContentHash: digest,
+		Author:      meta.Author,
+		Source:      r.String(),
+	}
+	if sig, err := signing.ParseSignature(signature); err == nil {
+		subject.Signature = sig.Signature
+		subject.KeyID = sig.KeyID
+	}
+	if err := checkSignature(subject); err != nil {
+		return nil, err
+	}
+
+	return &fetchedPack{
+		Files: files,
+		Type:  meta.Type,
+		Locked: manifest.LockedPack{
+			Name:        meta.Name,
+			Version:     meta.Version,
+			Source:      string(r.Kind),
+			Ref:         toProject(r.String()),
+			ContentHash: digest,
+		},
+	}, nil
+}
+
+// readArchive reads an archive file, or downloads it
+func readArchive(ctx context.Context, r packref.PackRef) ([]byte, error) {
+	if r.IsURL() {
+		if offlineFlag {
+			return nil, fmt.Errorf("archive URLs are not available offline: %s", r)
+		}
+		return api.DownloadArchive(ctx, r.Path)
+	}
+
+	f, err := os.Open(filepath.FromSlash(r.Path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := archive.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,417 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/cache"
+	"github.com/tunajam/packs/internal/config"
+	"github.com/tunajam/packs/internal/manifest"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/packref"
+)
+
+func CacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and manage the local pack cache",
+		Long: `Inspect and manage the local cache of registry data.
+
+The cache lives in ~/.packs/cache (config key cache.dir). Search results,
+pack metadata and pack content are cached for cache.ttl and served by
+--offline.
+
+COMMANDS:
+  packs cache ls                        List cached entries
+  packs cache verify                    Re-hash cached content and the store
+  packs cache prune --older-than 7d     Remove old entries
+  packs cache prune --max-size 50MB     Shrink the cache to a size
+  packs cache clear                     Delete the whole cache
+  packs cache warm <pack>...            Pre-fetch packs
+  packs cache warm --from-lock          Pre-fetch everything in packs.lock
+
+EXAMPLES:
+  packs cache warm commit-message react-patterns   # before a flight
+  packs cache warm --from-lock                     # in a Dockerfile`,
+	}
+
+	cmd.AddCommand(cacheLsCmd())
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Recompute SHA-256 of cached content and store entries",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheVerify()
+		},
+	})
+	cmd.AddCommand(cachePruneCmd())
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Delete the whole cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := newCache()
+			size := c.Size()
+			if err := c.Clear(); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Cleared %s (%s)\n", c.Dir(), formatBytes(size))
+			return nil
+		},
+	})
+	cmd.AddCommand(cacheWarmCmd())
+
+	return cmd
+}
+
+// cacheEntryInfo is a cache entry for JSON output
+type cacheEntryInfo struct {
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+	Name        string    `json:"name,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	Registry    string    `json:"registry,omitempty"`
+	ContentHash string    `json:"content_hash,omitempty"`
+	Size        int64     `json:"size"`
+	StoredAt    time.Time `json:"stored_at"`
+	Fresh       bool      `json:"fresh"`
+}
+
+func cacheLsCmd() *cobra.Command {
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List cached entries with size, age and hash",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheLs(jsonFlag)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output as JSON")
+
+	return cmd
+}
+
+func runCacheLs(jsonOutput bool) error {
+	c := newCache()
+	entries, err := c.Entries("")
+	if err != nil {
+		return err
+	}
+
+	var infos []cacheEntryInfo
+	for _, e := range entries {
+		size := e.Size()
+		for _, hash := range e.Hashes() {
+			if info, err := os.Stat(c.ContentPath(hash)); err == nil {
+				size += info.Size()
+			}
+		}
+		registry, key, _ := strings.Cut(e.Key, "|")
+		infos = append(infos, cacheEntryInfo{
+			Kind:        e.Kind,
+			Key:         key,
+			Name:        e.Name,
+			Version:     e.Version,
+			Registry:    registry,
+			ContentHash: e.ContentHash,
+			Size:        size,
+			StoredAt:    e.StoredAt,
+			Fresh:       c.Fresh(e),
+		})
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+
+	if len(infos) == 0 {
+		fmt.Printf("Cache is empty: %s\n", c.Dir())
+		return nil
+	}
+
+	fmt.Printf("\n  %s (%s)\n\n", c.Dir(), formatBytes(c.Size()))
+	for _, e := range infos {
+		// Pack keys show the requested version ("convex@latest")
+		label := e.Key
+		if e.Kind == cache.KindSearch {
+			label = "(search)"
+		}
+		hash := "-"
+		if e.ContentHash != "" {
+			hash = e.ContentHash[:12]
+		}
+		age := formatAge(time.Since(e.StoredAt))
+		if !e.Fresh {
+			age += " (stale)"
+		}
+		fmt.Printf("  %-8s %-30s %-16s %8s  %-14s %s\n",
+			e.Kind, truncate(label, 30), truncate(e.Registry, 16), formatBytes(e.Size), age, hash)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func runCacheVerify() error {
+	c := newCache()
+	entries, err := c.Entries(cache.KindPack)
+	if err != nil {
+		return err
+	}
+
+	// Pack entries for "latest" and the resolved version share a blob
+	checked := map[string]error{}
+	bad := 0
+	for _, e := range entries {
+		if _, done := checked[e.ContentHash]; done {
+			continue
+		}
+		err := c.Verify(e)
+		checked[e.ContentHash] = err
+		if err != nil {
+			bad++
+			fmt.Printf("  ✗ %s@%s  %s: %v\n", e.Name, e.Version, e.ContentHash[:12], err)
+		}
+	}
+
+	if bad > 0 {
+		return fmt.Errorf("%d cached packs failed verification\nRun 'packs cache clear' or re-fetch them with --no-cache", bad)
+	}
+	fmt.Printf("✓ Verified %d cached packs\n", len(checked))
+	return verifyStore()
+}
+
+// verifyStore re-hashes the store entries installs link to, which an edit
+// through a link changes for every install sharing the entry
+func verifyStore() error {
+	store := newPackStore()
+	entries, err := store.Entries()
+	if err != nil {
+		return err
+	}
+
+	bad := 0
+	for _, e := range entries {
+		err := store.Verify(e.Hash)
+		if err == nil {
+			continue
+		}
+		bad++
+		fmt.Printf("  ✗ store %s: %v\n", e.Hash[:12], err)
+		for _, r := range e.Refs {
+			fmt.Printf("    ↳ %s in %s\n", r.Name, r.Root)
+		}
+	}
+
+	if bad > 0 {
+		return fmt.Errorf("%d store entries failed verification\nReinstall a pack linked to them with 'packs get <pack> --force' to restore them", bad)
+	}
+	fmt.Printf("✓ Verified %d store entries\n", len(entries))
+	return nil
+}
+
+func cachePruneCmd() *cobra.Command {
+	var olderThanFlag string
+	var maxSizeFlag string
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old entries or shrink the cache",
+		Long: `Remove cached entries older than a duration, and/or the oldest entries
+until the cache fits in a size. Without flags the cache is shrunk to
+cache.max_size. Content no longer referenced is always removed.
+
+EXAMPLES:
+  packs cache prune --older-than 7d
+  packs cache prune --max-size 20MB`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCachePrune(olderThanFlag, maxSizeFlag)
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThanFlag, "older-than", "", "Remove entries older than this (e.g. 12h, 7d)")
+	cmd.Flags().StringVar(&maxSizeFlag, "max-size", "", "Shrink the cache to this size (e.g. 50MB)")
+
+	return cmd
+}
+
+func runCachePrune(olderThan, maxSize string) error {
+	var age time.Duration
+	var size int64
+	var err error
+
+	if olderThan != "" {
+		if age, err = parseAge(olderThan); err != nil {
+			return err
+		}
+	}
+	if maxSize != "" {
+		if size, err = config.ParseSize(maxSize); err != nil {
+			return err
+		}
+	}
+	if olderThan == "" && maxSize == "" {
+		size = loadConfig().Size("cache.max_size")
+	}
+
+	c := newCache()
+	freed, err := c.Prune(age, size)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Pruned %s, cache is now %s\n", formatBytes(freed), formatBytes(c.Size()))
+	return nil
+}
+
+func cacheWarmCmd() *cobra.Command {
+	var fromLockFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "warm [pack...]",
+		Short: "Pre-fetch packs into the cache",
+		Long: `Fetch packs from the registries into the cache so they can be installed
+with --offline later, e.g. before building an air-gapped image.
+
+EXAMPLES:
+  packs cache warm commit-message react-patterns@^1.0
+  packs cache warm --from-lock`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if fromLockFlag == (len(args) > 0) {
+				return fmt.Errorf("pass pack names or --from-lock")
+			}
+			return runCacheWarm(args, fromLockFlag)
+		},
+	}
+
+	cmd.Flags().BoolVar(&fromLockFlag, "from-lock", false, "Warm every pack in "+manifest.LockFile)
+
+	return cmd
+}
+
+func runCacheWarm(names []string, fromLock bool) error {
+	if offlineFlag {
+		return fmt.Errorf("can't warm the cache with --offline")
+	}
+
+	type target struct {
+		name, version, hash string
+	}
+	var targets []target
+
+	if fromLock {
+		lock, err := manifest.ReadLock(lockPath())
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no %s in this project", manifest.LockFile)
+		}
+		if err != nil {
+			return err
+		}
+		for _, p := range lock.Packs {
+			if p.Source != "registry" {
+				fmt.Printf("  - %s: skipped, %s packs are fetched directly\n", p.Name, p.Source)
+				continue
+			}
+			targets = append(targets, target{name: p.Name, version: p.Version, hash: p.ContentHash})
+		}
+	} else {
+		for _, n := range names {
+			r, err := packref.Parse(n)
+			if err != nil {
+				return err
+			}
+			if r.Kind != packref.Registry {
+				fmt.Printf("  - %s: skipped, %s packs are fetched directly\n", r, r.Kind)
+				continue
+			}
+			targets = append(targets, target{name: r.Name, version: r.Version})
+		}
+	}
+
+	// Always fetch, so warming refreshes stale entries too
+	registries := buildRegistries("", api.CacheBypass)
+	ctx := context.Background()
+
+	failed := 0
+	for _, t := range targets {
+		version, err := api.ResolveVersion(ctx, registries, t.name, t.version, false)
+		var p *api.Pack
+		if err == nil {
+			p, err = registries.Get(ctx, t.name, version)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("  ✗ %s: %v\n", packLabel(t.name, t.version), err)
+			continue
+		}
+		if digest := packfmt.Digest(p.FileSet()); t.hash != "" && t.hash != digest {
+			fmt.Printf("  ! %s@%s: registry digest %s differs from %s\n", p.Name, p.Version, digest, manifest.LockFile)
+		}
+		registries.ListVersions(ctx, t.name)
+		fmt.Printf("  ✓ %s@%s  [%s]\n", p.Name, p.Version, p.Registry)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d packs could not be fetched", failed, len(targets))
+	}
+	fmt.Printf("\n✓ Warmed %d packs into %s\n", len(targets), newCache().Dir())
+	return nil
+}
+
+func packLabel(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "@" + version
+}
+
+// parseAge parses durations, also accepting days ("7d")
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return d, nil
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
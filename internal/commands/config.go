@@ -3,9 +3,14 @@ package commands
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/config"
+	"github.com/tunajam/packs/internal/signing"
 )
 
 func ConfigCmd() *cobra.Command {
@@ -15,20 +20,75 @@ func ConfigCmd() *cobra.Command {
 		Long: `View and manage packs configuration.
 
 CONFIGURATION:
-  packs stores configuration in ~/.packs/config.yaml
+  packs reads configuration from these layers, later ones winning:
 
-  registry:     URL of the packs registry (default: https://api.packs.sh)
-  skills_dir:   Where to install packs (auto-detected by default)
-  telemetry:    Enable anonymous usage statistics (default: true)
+    1. Defaults
+    2. User config      ~/.packs/config.yaml
+    3. Project config   .packs/config.yaml (current dir or nearest parent)
+    4. Environment      PACKS_REGISTRY, PACKS_SKILLS_DIR, ...
+    5. Flags            --registry
+
+  registry:        Registry URL or local directory (default: packs.sh)
+  skills_dir:      Where to install packs (auto-detected by default); in
+                   project config it is the project scope directory
+  telemetry:       Enable anonymous usage statistics (default: true)
+  cache.dir:       Local cache directory (default: ~/.packs/cache)
+  cache.ttl:       How long cached data stays fresh (default: 1h)
+  cache.max_size:  Maximum cache size (default: 100MB)
+  store.dir:       Store installed packs link to (default: ~/.packs/store)
+  ui.color:        auto, always or never (default: auto)
+  signing.policy:  off, verify or require (default: verify); project
+                   config can only make it stricter
+  trust_project_config:
+                   Let project config add trusted keys, allow_unsigned
+                   and registry auth (default: false; user config only)
 
 COMMANDS:
-  packs config              Show current configuration
-  packs config path         Show config file path
-  packs config reset        Reset to defaults
+  packs config                    Show current configuration
+  packs config list               Show every key, value and source
+  packs config get <key>          Print a value
+  packs config set <key> <value>  Set a value (--project for project config)
+  packs config unset <key>        Remove a value (--project for project config)
+  packs config edit               Open the config file in $EDITOR
+  packs config path               Show config file path
+  packs config reset              Reset to defaults
+  packs config add-registry <url> [--priority N] [--auth TOKEN]
+  packs config remove-registry <url|name>
+  packs config trust <public-key|file> [--author NAME] [--registry NAME]
+  packs config untrust <key-id>
+
+REGISTRIES:
+  Registries are checked in priority order (lower first). packs.sh is
+  implicit at priority 99. A registry URL may also be a local directory.
+
+  registries:
+    - url: https://packs.acme.corp
+      priority: 1
+      auth: bearer $ACME_PACKS_TOKEN
+
+SIGNATURES:
+  Packs signed with 'packs sign' are verified against trusted keys. A key
+  can be limited to one author or registry. With signing.policy "require",
+  packs without a trusted signature are refused unless their source is
+  allowlisted.
+
+  trusted_keys:
+    - key: ed25519:Rk9P...
+      author: tunajam
+  signing:
+    policy: require
+    allow_unsigned: [packs.acme.corp, "gh:acme/*"]
 
 ENVIRONMENT VARIABLES:
-  PACKS_REGISTRY    Override registry URL
-  PACKS_SKILLS_DIR  Override skills directory
+  PACKS_REGISTRY        Override registry URL (PACKS_API_URL also works)
+  PACKS_SKILLS_DIR      Override skills directory
+  PACKS_CACHE_DIR       Override cache directory
+  PACKS_CACHE_TTL       Override cache TTL
+  PACKS_CACHE_MAX_SIZE  Override cache size limit
+  PACKS_STORE_DIR       Override store directory
+  PACKS_COLOR           Override color mode (NO_COLOR also works)
+  PACKS_SIGNING_POLICY  Override signature policy
+  PACKS_TRUST_PROJECT_CONFIG  Trust project config (see trust_project_config)
   PACKS_NO_TELEMETRY=1  Disable telemetry`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return showConfig()
@@ -52,60 +112,416 @@ ENVIRONMENT VARIABLES:
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Show every config key with its value and source",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listConfig()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if config.LookupKey(args[0]) == nil {
+				return config.Validate(args[0], "")
+			}
+			fmt.Println(loadConfig().Get(args[0]))
+			return nil
+		},
+	})
+
+	cmd.AddCommand(configWriteCmd("set <key> <value>", "Set a config value", 2, func(path string, args []string) error {
+		if err := config.Validate(args[0], args[1]); err != nil {
+			return err
+		}
+		if err := config.Set(path, args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Set %s = %s in %s\n", args[0], args[1], path)
+		return nil
+	}))
+
+	cmd.AddCommand(configWriteCmd("unset <key>", "Remove a config value", 1, func(path string, args []string) error {
+		if config.LookupKey(args[0]) == nil {
+			return config.Validate(args[0], "")
+		}
+		if err := config.Unset(path, args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Unset %s in %s\n", args[0], path)
+		return nil
+	}))
+
+	cmd.AddCommand(configWriteCmd("edit", "Open the config file in $EDITOR", 0, func(path string, args []string) error {
+		return editConfig(path)
+	}))
+
+	cmd.AddCommand(addRegistryCmd())
+	cmd.AddCommand(configWriteCmd("remove-registry <url|name>", "Remove a registry", 1, func(path string, args []string) error {
+		return removeRegistry(path, args[0])
+	}))
+
+	cmd.AddCommand(trustKeyCmd())
+	cmd.AddCommand(configWriteCmd("untrust <key-id>", "Remove a trusted signing key", 1, func(path string, args []string) error {
+		return untrustKey(path, args[0])
+	}))
+
+	return cmd
+}
+
+// configWriteCmd builds a subcommand that edits the user config file, or
+// the project config file with --project
+func configWriteCmd(use, short string, nargs int, run func(path string, args []string) error) *cobra.Command {
+	var projectFlag bool
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(nargs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(configFileFor(projectFlag), args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&projectFlag, "project", false, "Write to the project config (.packs/config.yaml)")
+
+	return cmd
+}
+
+// configFileFor returns the file config writes go to
+func configFileFor(project bool) string {
+	if !project {
+		return getConfigPath()
+	}
+	if path := config.ProjectPath(); path != "" {
+		return path
+	}
+	return filepath.Join(".packs", "config.yaml")
+}
+
+func addRegistryCmd() *cobra.Command {
+	var nameFlag string
+	var priorityFlag int
+	var authFlag string
+	var projectFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "add-registry <url>",
+		Short: "Add a registry (lower priority is checked first)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return addRegistry(configFileFor(projectFlag), config.Registry{
+				Name:     nameFlag,
+				URL:      args[0],
+				Priority: priorityFlag,
+				Auth:     authFlag,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&nameFlag, "name", "", "Label shown next to results (default: host name)")
+	cmd.Flags().IntVarP(&priorityFlag, "priority", "p", 0, "Resolution priority, lower is checked first")
+	cmd.Flags().StringVar(&authFlag, "auth", "", "Auth for this registry, e.g. 'bearer $TOKEN'")
+	cmd.Flags().BoolVar(&projectFlag, "project", false, "Write to the project config (.packs/config.yaml)")
+
+	return cmd
+}
+
+func addRegistry(path string, r config.Registry) error {
+	registries, err := config.ReadRegistries(path)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range registries {
+		if existing.URL == r.URL {
+			return fmt.Errorf("registry already configured: %s\nRemove it first with: packs config remove-registry %s", r.URL, r.URL)
+		}
+	}
+
+	registries = append(registries, r)
+	if err := config.Set(path, "registries", registries); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Added registry %s (priority %d)\n", r.URL, r.Priority)
+	return nil
+}
+
+func removeRegistry(path, ref string) error {
+	registries, err := config.ReadRegistries(path)
+	if err != nil {
+		return err
+	}
+
+	var kept []config.Registry
+	for _, r := range registries {
+		if r.URL == ref || r.Name == ref {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if len(kept) == len(registries) {
+		return fmt.Errorf("registry not configured in %s: %s", path, ref)
+	}
+
+	if len(kept) == 0 {
+		err = config.Unset(path, "registries")
+	} else {
+		err = config.Set(path, "registries", kept)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Removed registry %s\n", ref)
+	return nil
+}
+
+func trustKeyCmd() *cobra.Command {
+	var authorFlag string
+	var registryFlag string
+	var commentFlag string
+	var projectFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "trust <public-key|file>",
+		Short: "Trust a publisher's signing key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+			if data, err := os.ReadFile(key); err == nil {
+				key = strings.TrimSpace(string(data))
+			}
+			return trustKey(configFileFor(projectFlag), signing.TrustedKey{
+				Key:      key,
+				Author:   authorFlag,
+				Registry: registryFlag,
+				Comment:  commentFlag,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&authorFlag, "author", "", "Only trust this key for packs by this author")
+	cmd.Flags().StringVar(&registryFlag, "registry", "", "Only trust this key for packs from this registry")
+	cmd.Flags().StringVar(&commentFlag, "comment", "", "Note stored with the key")
+	cmd.Flags().BoolVar(&projectFlag, "project", false, "Write to the project config (.packs/config.yaml)")
+
 	return cmd
 }
 
+func trustKey(path string, key signing.TrustedKey) error {
+	pub, err := signing.ParsePublicKey(key.Key)
+	if err != nil {
+		return err
+	}
+	key.Key = signing.EncodePublicKey(pub)
+	id := signing.KeyID(pub)
+
+	keys, err := config.ReadTrustedKeys(path)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.ID() == id && k.Author == key.Author && k.Registry == key.Registry {
+			return fmt.Errorf("key already trusted: %s", id)
+		}
+	}
+
+	keys = append(keys, key)
+	if err := config.Set(path, "trusted_keys", keys); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Trusted key %s\n", id)
+	return nil
+}
+
+func untrustKey(path, id string) error {
+	keys, err := config.ReadTrustedKeys(path)
+	if err != nil {
+		return err
+	}
+
+	var kept []signing.TrustedKey
+	for _, k := range keys {
+		if k.ID() != id {
+			kept = append(kept, k)
+		}
+	}
+	if len(kept) == len(keys) {
+		return fmt.Errorf("key not trusted in %s: %s", path, id)
+	}
+
+	if len(kept) == 0 {
+		err = config.Unset(path, "trusted_keys")
+	} else {
+		err = config.Set(path, "trusted_keys", kept)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Removed trusted key %s\n", id)
+	return nil
+}
+
 func showConfig() error {
-	home, _ := os.UserHomeDir()
-	configPath := getConfigPath()
-	skillsDir := detectAgentSkillsDir()
+	cfg := loadConfig()
 
-	registry := "https://api.packs.sh"
-	if env := os.Getenv("PACKS_REGISTRY"); env != "" {
-		registry = env + " (from env)"
+	// describe renders a value with its source when it isn't a default
+	describe := func(key string) string {
+		v, _ := cfg.Lookup(key)
+		if v.Source == config.SourceDefault {
+			return v.Value
+		}
+		return fmt.Sprintf("%s (from %s)", v.Value, v.Describe())
 	}
 
+	// Where 'packs get --scope' installs; a plain 'packs get' uses the
+	// default scope
+	defaultScope := detectLocation("").Scope
+	installDir := func(scope agents.Scope) string {
+		loc := detectLocation(scope)
+		dir := fmt.Sprintf("%s (%s, auto-detected)", loc.Dir, loc.Target.Title())
+		if loc.Target.Name() == "custom" {
+			dir = describe("skills_dir")
+		}
+		if scope == defaultScope {
+			dir += " [default]"
+		}
+		return dir
+	}
+	projectDir := installDir(agents.ScopeProject)
+	userDir := installDir(agents.ScopeUser)
+
 	telemetry := "enabled"
-	if os.Getenv("PACKS_NO_TELEMETRY") == "1" {
-		telemetry = "disabled (from env)"
+	if !cfg.Bool("telemetry") {
+		telemetry = "disabled"
+	}
+	if v, _ := cfg.Lookup("telemetry"); v.Source != config.SourceDefault {
+		telemetry += fmt.Sprintf(" (from %s)", v.Describe())
+	}
+
+	projectPath := cfg.ProjectPath
+	if projectPath == "" {
+		projectPath = "(none)"
 	}
 
 	fmt.Printf("\n  📦 packs configuration\n")
 	fmt.Printf("  %s\n\n", "────────────────────────────────────────")
-	fmt.Printf("  %-14s %s\n", "Config file:", configPath)
-	fmt.Printf("  %-14s %s\n", "Registry:", registry)
-	fmt.Printf("  %-14s %s\n", "Skills dir:", skillsDir)
+	fmt.Printf("  %-14s %s\n", "Config file:", cfg.UserPath)
+	fmt.Printf("  %-14s %s\n", "Project file:", projectPath)
+	fmt.Printf("  %-14s %s\n", "Registry:", describe("registry"))
+	fmt.Printf("  %-14s %s\n", "Project packs:", projectDir)
+	fmt.Printf("  %-14s %s\n", "User packs:", userDir)
 	fmt.Printf("  %-14s %s\n", "Telemetry:", telemetry)
-	fmt.Printf("  %-14s %s\n", "Cache:", filepath.Join(home, ".packs", "cache"))
+	fmt.Printf("  %-14s %s\n", "Cache:", describe("cache.dir"))
+	fmt.Printf("  %-14s %s\n", "Store:", describe("store.dir"))
+
+	fmt.Printf("\n  Registries (checked in order):\n")
+	for _, r := range newRegistries("").Registries() {
+		fmt.Printf("    %-3d %s\n", r.Priority, r.Name)
+	}
+
+	fmt.Printf("\n  Signatures:    %s\n", describe("signing.policy"))
+	for _, k := range cfg.TrustedKeys {
+		scope := ""
+		if k.Author != "" {
+			scope += " author=" + k.Author
+		}
+		if k.Registry != "" {
+			scope += " registry=" + k.Registry
+		}
+		fmt.Printf("    %s%s\n", k.ID(), scope)
+	}
 	fmt.Println()
 
 	return nil
 }
 
-func getConfigPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".packs", "config.yaml")
+func listConfig() error {
+	cfg := loadConfig()
+
+	fmt.Println()
+	for _, v := range cfg.Values() {
+		value := v.Value
+		if value == "" {
+			value = "(unset)"
+		}
+		fmt.Printf("  %-20s %-32s %s\n", v.Key, value, dimStyle.Render(v.Describe()))
+	}
+	fmt.Println()
+
+	return nil
 }
 
-func resetConfig() error {
-	configPath := getConfigPath()
-	
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+func editConfig(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(defaultConfig), 0600); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command(editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor failed: %w", err)
+	}
+
+	// Catch mistakes now rather than on the next command
+	if _, err := config.Load(); err != nil {
 		return err
 	}
+	return nil
+}
 
-	defaultConfig := `# packs configuration
+func getConfigPath() string {
+	return config.Path()
+}
+
+const defaultConfig = `# packs configuration
 # https://packs.sh
 
-registry: https://api.packs.sh
+# registry: https://packs-api.fly.dev
 telemetry: true
 
 # Override auto-detected skills directory:
 # skills_dir: ~/.packs/skills
+
+# cache:
+#   ttl: 1h
+#   max_size: 100MB
+
+# ui:
+#   color: auto
 `
 
-	if err := os.WriteFile(configPath, []byte(defaultConfig), 0644); err != nil {
+func resetConfig() error {
+	configPath := getConfigPath()
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(configPath, []byte(defaultConfig), 0600); err != nil {
 		return err
 	}
 
@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/atomicfile"
+	"github.com/tunajam/packs/internal/diff"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/managed"
+	packfmt "github.com/tunajam/packs/internal/pack"
+)
+
+// errPackExists is returned when a pack is already where it's being
+// installed
+var errPackExists = errors.New("pack already exists")
+
+// What get does with a pack that's already there. Side by side and merge
+// are only offered at the prompt.
+const (
+	conflictSkip       = "skip"
+	conflictOverwrite  = "overwrite"
+	conflictFail       = "fail"
+	conflictBackup     = "backup"
+	conflictSideBySide = "side-by-side"
+	conflictMerge      = "merge"
+)
+
+// conflictMode checks --on-conflict, which --force is shorthand for
+func conflictMode(onConflict string, force bool) (string, error) {
+	switch onConflict {
+	case "", conflictSkip, conflictOverwrite, conflictFail, conflictBackup:
+	default:
+		return "", fmt.Errorf("unknown --on-conflict: %s\nUse skip, overwrite, fail or backup", onConflict)
+	}
+	if force {
+		if onConflict != "" && onConflict != conflictOverwrite {
+			return "", fmt.Errorf("--force overwrites, so it can't be used with --on-conflict=%s", onConflict)
+		}
+		return conflictOverwrite, nil
+	}
+	return onConflict, nil
+}
+
+// installPlan is what get installs in one location, once conflicts are
+// settled
+type installPlan struct {
+	loc     agents.Location
+	fetched *fetchedPack
+	opts    installOpts
+	how     string // how a conflict was resolved, if there was one
+}
+
+// planInstall settles what to do when the pack is already in a location:
+// as --on-conflict says, or by asking. It returns nil when the location
+// should be left alone.
+func planInstall(ctx context.Context, loc agents.Location, fetched *fetchedPack, onConflict string, copyFiles bool) (*installPlan, error) {
+	plan := &installPlan{loc: loc, fetched: fetched, opts: installOpts{copy: copyFiles}}
+	if onConflict == conflictOverwrite {
+		plan.opts.force = true
+		return plan, nil
+	}
+
+	name := fetched.Locked.Name
+	files, _, err := layoutFor(loc, fetched)
+	if err != nil {
+		return nil, err
+	}
+	exists := checkExisting(loc, name, files)
+	if exists == nil {
+		return plan, nil
+	}
+	if !errors.Is(exists, errPackExists) {
+		return nil, exists
+	}
+
+	plan.how = onConflict
+	if plan.how == "" && isInteractive() {
+		if plan.how, err = promptConflict(ctx, loc, name, files); err != nil {
+			return nil, err
+		}
+	}
+	switch plan.how {
+	case conflictSkip:
+		fmt.Printf("! Kept the %s already in %s\n", name, conflictWhere(loc, files))
+		return nil, nil
+	case conflictOverwrite:
+		plan.opts.force = true
+	case conflictBackup:
+		plan.opts.force = true
+		plan.opts.backup = installed.BackupPath(loc.Dir, name)
+	case conflictMerge:
+		plan.opts.force = true
+		if plan.opts.merged, err = markConflicts(loc, name, files); err != nil {
+			return nil, err
+		}
+	case conflictSideBySide:
+		if plan.fetched, err = sideBySide(loc, fetched); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, exists
+	}
+	return plan, nil
+}
+
+// printGot reports a pack installed by get
+func printGot(p *installed.Pack, plan *installPlan) {
+	if p.Managed {
+		fmt.Printf("✓ Installed %s as a managed block in %s\n", p.Name, p.Dir)
+	} else {
+		fmt.Printf("✓ Installed %s (%d files) to %s\n", p.Name, len(p.Outputs), p.Dir)
+	}
+
+	switch plan.how {
+	case conflictBackup:
+		fmt.Printf("  ↳ the one it replaced is in %s\n", plan.opts.backup)
+	case conflictSideBySide:
+		fmt.Printf("  ↳ next to %s, which was left as it was\n", plan.fetched.Pack)
+	case conflictMerge:
+		files, _ := p.ReadFiles()
+		for _, f := range files {
+			if diff.HasConflicts(f.Content) {
+				fmt.Printf("  ! resolve the conflict markers in %s\n", filepath.Join(p.Root, filepath.FromSlash(f.Path)))
+			}
+		}
+	}
+}
+
+// stdin is shared by prompts, so input one doesn't use isn't lost
+var stdin = bufio.NewReader(os.Stdin)
+
+// promptConflict asks what to do about a pack that's already installed
+func promptConflict(ctx context.Context, loc agents.Location, name string, files []packfmt.File) (string, error) {
+	fmt.Printf("! %s is already installed in %s\n", name, conflictWhere(loc, files))
+	for {
+		fmt.Printf("  [d]iff, [o]verwrite, [b]ack up and overwrite, [k]eep mine,\n  [s]ide by side as %s-new, [m]erge with conflict markers? ", name)
+		answer, err := readAnswer(ctx)
+		if errors.Is(err, io.EOF) {
+			// Ctrl-D
+			fmt.Println()
+			return conflictFail, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch strings.ToLower(answer) {
+		case "d", "diff":
+			if err := printConflictDiff(loc, name, files); err != nil {
+				return "", err
+			}
+		case "o", "overwrite":
+			return conflictOverwrite, nil
+		case "b", "backup":
+			return conflictBackup, nil
+		case "k", "keep":
+			return conflictSkip, nil
+		case "s", "side":
+			return conflictSideBySide, nil
+		case "m", "merge":
+			return conflictMerge, nil
+		}
+	}
+}
+
+// conflictWhere names where a pack is in the way: the agent directory, or
+// the file holding its managed block
+func conflictWhere(loc agents.Location, files []packfmt.File) string {
+	if agents.IsInline(loc.Target) && len(files) > 0 {
+		return filepath.Join(loc.Dir, filepath.FromSlash(files[0].Path))
+	}
+	return loc.Dir
+}
+
+// readAnswer reads a line from stdin, giving up when ctx is cancelled
+func readAnswer(ctx context.Context) (string, error) {
+	type line struct {
+		text string
+		err  error
+	}
+	read := make(chan line, 1)
+	go func() {
+		text, err := stdin.ReadString('\n')
+		read <- line{text, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		fmt.Println()
+		return "", whenInterrupted(ctx, nil)
+	case l := <-read:
+		if l.err != nil && l.text == "" {
+			return "", l.err
+		}
+		return strings.TrimSpace(l.text), nil
+	}
+}
+
+// printConflictDiff shows how what's installed differs from the incoming
+// files
+func printConflictDiff(loc agents.Location, name string, files []packfmt.File) error {
+	existing, err := existingContent(loc, name, files)
+	if err != nil {
+		return err
+	}
+	same := true
+	for _, f := range files {
+		old, ok := existing[f.Path]
+		from := "installed/" + f.Path
+		if !ok {
+			from = "/dev/null"
+		}
+		if d := diff.Unified(from, "incoming/"+f.Path, old, incomingContent(loc, name, f)); d != "" {
+			fmt.Print(d)
+			same = false
+		}
+	}
+	if same {
+		fmt.Println("  No differences")
+	}
+	return nil
+}
+
+// markConflicts combines each file that's in place of one of the pack's,
+// and differs, with the incoming version, using conflict markers
+func markConflicts(loc agents.Location, name string, files []packfmt.File) (map[string][]byte, error) {
+	existing, err := existingContent(loc, name, files)
+	if err != nil {
+		return nil, err
+	}
+	merged := map[string][]byte{}
+	for _, f := range files {
+		incoming := incomingContent(loc, name, f)
+		if old, ok := existing[f.Path]; ok && !bytes.Equal(old, incoming) {
+			merged[f.Path], _ = diff.Conflict(old, incoming, "installed", "incoming")
+		}
+	}
+	return merged, nil
+}
+
+// existingContent reads what's in place of each file laid out for a pack:
+// the file on disk, or for managed blocks the block's body. Files that
+// aren't there are left out.
+func existingContent(loc agents.Location, name string, files []packfmt.File) (map[string][]byte, error) {
+	content := map[string][]byte{}
+	for _, f := range files {
+		path := filepath.Join(loc.Dir, filepath.FromSlash(f.Path))
+		if agents.IsInline(loc.Target) {
+			data, _, err := readHostFile(path)
+			if err != nil {
+				return nil, err
+			}
+			b, err := managed.Find(data, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if b != nil {
+				content[f.Path] = b.Body
+			}
+			continue
+		}
+		if !fileExists(path) {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		content[f.Path] = data
+	}
+	return content, nil
+}
+
+// incomingContent is what a pack's file puts in place: the file, or for
+// managed blocks the block's body
+func incomingContent(loc agents.Location, name string, f packfmt.File) []byte {
+	if agents.IsInline(loc.Target) {
+		return managed.New(name, "", f.Content).Body
+	}
+	return f.Content
+}
+
+// sideBySide returns the pack named for installing next to the one that's
+// there: <name>-new, or <name>-new-2 and so on when that's taken too
+func sideBySide(loc agents.Location, fetched *fetchedPack) (*fetchedPack, error) {
+	for i := 1; ; i++ {
+		f := *fetched
+		f.Pack = fetched.Locked.Name
+		f.Locked.Name = fetched.Locked.Name + "-new"
+		if i > 1 {
+			f.Locked.Name += "-" + strconv.Itoa(i)
+		}
+		if _, err := installed.Read(loc.Dir, f.Locked.Name); err == nil {
+			continue
+		}
+		files, _, err := layoutFor(loc, &f)
+		if err != nil {
+			return nil, err
+		}
+		err = checkExisting(loc, f.Locked.Name, files)
+		if errors.Is(err, errPackExists) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &f, nil
+	}
+}
+
+// backUp stages a copy of the entries a pack's files are about to replace
+// and swaps it in at dir as part of tx, so it's only kept if the install
+// goes through. Copies are plain files, not links to the store.
+func backUp(tx *installTx, root string, files []packfmt.File, dir string) error {
+	stage, err := tx.stageIn(installed.StateDir())
+	if err != nil {
+		return err
+	}
+	staged := filepath.Join(stage, "backup")
+	seen := map[string]bool{}
+	for _, f := range files {
+		top, _, _ := strings.Cut(f.Path, "/")
+		if seen[top] {
+			continue
+		}
+		seen[top] = true
+		if err := copyEntry(filepath.Join(root, top), filepath.Join(staged, top)); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", top, err)
+		}
+	}
+	if _, err := os.Stat(staged); err != nil {
+		return nil
+	}
+	return tx.replace(stage, dir, staged)
+}
+
+// copyEntry copies a file, or a directory's files, following symlinks.
+// Nothing at src copies nothing.
+func copyEntry(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) && path == src {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return atomicfile.Write(filepath.Join(dst, rel), data, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/diff"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/manifest"
+	packfmt "github.com/tunajam/packs/internal/pack"
+)
+
+func DiffCmd() *cobra.Command {
+	var agentFlag string
+	var scopeFlag string
+
+	cmd := &cobra.Command{
+		Use:   "diff <pack>",
+		Short: "Show local edits to an installed pack",
+		Long: `Show how an installed pack's files differ from the version upstream
+shipped, as a unified diff.
+
+Every install keeps a copy of the files as upstream shipped them, in
+~/.packs/base/ away from the agent's rules. 'packs update' merges your
+edits into a new version against that copy. Packs installed before copies
+were kept are compared with the installed version fetched again.
+
+FLAGS:
+  -a, --agent <name>   Only the pack installed for one agent
+      --scope <scope>  Only the pack installed in project or user directories
+
+EXAMPLES:
+  packs diff convex
+  packs diff convex --agent cursor
+  packs diff convex > convex.patch`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiff(cmd.Context(), args[0], agentFlag, scopeFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentFlag, "agent", "a", "", "Only this agent")
+	cmd.Flags().StringVar(&scopeFlag, "scope", "", "Only this scope (project, user)")
+
+	return cmd
+}
+
+func runDiff(ctx context.Context, name, agent, scope string) error {
+	packs, err := installedPacks(agent, scope, "")
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, p := range packs {
+		if p.Name != name {
+			continue
+		}
+		found = true
+		if !p.Modified {
+			fmt.Printf("✓ %s has no local edits in %s\n", p.Name, p.Dir)
+			continue
+		}
+
+		base, err := upstreamFiles(ctx, p)
+		if err != nil {
+			return err
+		}
+		local, err := p.ReadFiles()
+		if err != nil {
+			return err
+		}
+		printEdits(p, base, local)
+	}
+	if !found {
+		return fmt.Errorf("pack not installed: %s\nSee installed packs with: packs list", name)
+	}
+	return nil
+}
+
+// printEdits prints a unified diff of each installed file against upstream
+func printEdits(p *installed.Pack, base, local []packfmt.File) {
+	upstream := map[string][]byte{}
+	for _, f := range base {
+		upstream[f.Path] = f.Content
+	}
+	now := map[string][]byte{}
+	for _, f := range local {
+		now[f.Path] = f.Content
+	}
+
+	for _, rel := range p.Paths() {
+		from := p.Name + "@" + installedVersion(p) + "/" + rel
+		to := filepath.Join(p.Root, filepath.FromSlash(rel))
+		data, ok := now[rel]
+		if !ok {
+			to = "/dev/null"
+		}
+		fmt.Print(diff.Unified(from, to, upstream[rel], data))
+	}
+}
+
+// installedVersion labels the version a pack was installed at
+func installedVersion(p *installed.Pack) string {
+	if p.Version == "" {
+		return shortCommit(p.Commit)
+	}
+	return p.Version
+}
+
+// upstreamFiles returns an installed pack's files as upstream shipped them:
+// the copy kept at install or, for packs installed before copies were
+// kept, the installed version fetched again and laid out the same way
+func upstreamFiles(ctx context.Context, p *installed.Pack) ([]packfmt.File, error) {
+	files, err := p.ReadBase()
+	if !errors.Is(err, os.ErrNotExist) {
+		return files, err
+	}
+
+	name := p.Name
+	if p.Pack != "" {
+		name = p.Pack
+	}
+	fetched, err := fetchLocked(ctx, manifest.LockedPack{
+		Name:        name,
+		Version:     p.Version,
+		Source:      p.Source,
+		Ref:         p.Ref,
+		Registry:    p.Registry,
+		Commit:      p.Commit,
+		ContentHash: p.ContentHash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("no upstream copy of %s is kept, and fetching %s again failed: %w", p.Name, installedVersion(p), err)
+	}
+	fetched.Locked.Name = p.Name
+
+	loc := locationFor(p)
+	if files, _, err = layoutFor(loc, fetched); err != nil {
+		return nil, err
+	}
+	if agents.IsInline(loc.Target) {
+		for i := range files {
+			files[i].Content = incomingContent(loc, p.Name, files[i])
+		}
+	}
+	return files, nil
+}
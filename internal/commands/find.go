@@ -20,8 +20,9 @@ type PackInfo struct {
 	Author      string   `json:"author"`
 	Stars       int      `json:"stars"`
 	Tags        []string `json:"tags,omitempty"`
-	Source      string   `json:"source"`     // "registry" or "github"
+	Source      string   `json:"source"`     // "registry", "github" or "cache"
 	SourceURL   string   `json:"source_url,omitempty"` // Original attribution URL
+	Registry    string   `json:"registry,omitempty"`   // Registry the result came from
 }
 
 func FindCmd() *cobra.Command {
@@ -71,7 +72,11 @@ EXAMPLES:
 }
 
 func runFind(query string, packType string, limit int, jsonOutput bool) error {
-	client := api.New()
+	if offlineFlag {
+		return runFindOffline(query, packType, limit, jsonOutput)
+	}
+
+	store := newStore()
 	ctx := context.Background()
 
 	opts := api.SearchOpts{
@@ -81,9 +86,12 @@ func runFind(query string, packType string, limit int, jsonOutput bool) error {
 		Sort:  "stars", // Default sort by popularity
 	}
 
-	packs, total, err := client.Search(ctx, opts)
+	packs, total, err := store.Search(ctx, opts)
 	if err != nil {
-		// If API fails, fall back to demo data for offline/dev use
+		if noCacheFlag {
+			return err
+		}
+		// If every registry fails, search whatever is cached
 		return runFindOffline(query, packType, limit, jsonOutput)
 	}
 
@@ -100,6 +108,7 @@ func runFind(query string, packType string, limit int, jsonOutput bool) error {
 			Tags:        p.Tags,
 			Source:      "registry",
 			SourceURL:   p.SourceURL,
+			Registry:    p.Registry,
 		})
 	}
 
@@ -125,8 +134,8 @@ func runFind(query string, packType string, limit int, jsonOutput bool) error {
 		case "prompt":
 			typeIcon = "💬"
 		}
-		fmt.Printf("  %s %-24s  ★ %-4d  %s\n",
-			typeIcon, p.Name, p.Stars, truncate(p.Description, 40))
+		fmt.Printf("  %s %-24s  ★ %-4d  %-14s  %s\n",
+			typeIcon, p.Name, p.Stars, "["+p.Registry+"]", truncate(p.Description, 40))
 	}
 
 	// If single result, show the exact command and source
@@ -144,37 +153,28 @@ func runFind(query string, packType string, limit int, jsonOutput bool) error {
 	return nil
 }
 
-// runFindOffline provides fallback demo data when API is unavailable
+// runFindOffline searches cached packs when registries are unavailable
 func runFindOffline(query string, packType string, limit int, jsonOutput bool) error {
-	packs := getDemoPacks()
-
-	// Filter by query
-	if query != "" {
-		query = strings.ToLower(query)
-		var filtered []PackInfo
-		for _, p := range packs {
-			if strings.Contains(strings.ToLower(p.Name), query) ||
-				strings.Contains(strings.ToLower(p.Description), query) {
-				filtered = append(filtered, p)
-			}
-		}
-		packs = filtered
-	}
-
-	// Filter by type
-	if packType != "" {
-		var filtered []PackInfo
-		for _, p := range packs {
-			if p.Type == packType {
-				filtered = append(filtered, p)
-			}
-		}
-		packs = filtered
-	}
+	cached := api.SearchCache(newCache(), api.SearchOpts{
+		Query: query,
+		Type:  packType,
+		Limit: int32(limit),
+	})
 
-	// Apply limit
-	if limit > 0 && len(packs) > limit {
-		packs = packs[:limit]
+	var packs []PackInfo
+	for _, p := range cached {
+		packs = append(packs, PackInfo{
+			Name:        p.Name,
+			Version:     p.Version,
+			Type:        p.Type,
+			Description: p.Description,
+			Author:      p.Author,
+			Stars:       int(p.Stars),
+			Tags:        p.Tags,
+			Source:      "cache",
+			SourceURL:   p.SourceURL,
+			Registry:    p.Registry,
+		})
 	}
 
 	// Output
@@ -186,11 +186,11 @@ func runFindOffline(query string, packType string, limit int, jsonOutput bool) e
 
 	// Human-readable output
 	if len(packs) == 0 {
-		fmt.Println("No packs found.")
+		fmt.Println("No cached packs found. Run 'packs find' online to populate the cache.")
 		return nil
 	}
 
-	fmt.Printf("\n  Found %d packs (offline mode):\n\n", len(packs))
+	fmt.Printf("\n  Found %d packs (offline mode, from cache):\n\n", len(packs))
 	for _, p := range packs {
 		typeIcon := "📦"
 		switch p.Type {
@@ -199,8 +199,8 @@ func runFindOffline(query string, packType string, limit int, jsonOutput bool) e
 		case "prompt":
 			typeIcon = "💬"
 		}
-		fmt.Printf("  %s %-24s  ★ %-4d  %s\n",
-			typeIcon, p.Name, p.Stars, truncate(p.Description, 40))
+		fmt.Printf("  %s %-24s  ★ %-4d  %-14s  %s\n",
+			typeIcon, p.Name, p.Stars, "["+p.Registry+"]", truncate(p.Description, 40))
 	}
 
 	// If single result, show the exact command
@@ -214,23 +214,6 @@ func runFindOffline(query string, packType string, limit int, jsonOutput bool) e
 	return nil
 }
 
-func getDemoPacks() []PackInfo {
-	return []PackInfo{
-		{Name: "commit-message", Version: "1.0.0", Type: "skill", Description: "Generate conventional commit messages", Author: "tunajam", Stars: 892, Source: "registry"},
-		{Name: "pr-description", Version: "1.0.0", Type: "skill", Description: "Write PR descriptions from branch diff", Author: "tunajam", Stars: 654, Source: "registry"},
-		{Name: "humanizer", Version: "1.0.0", Type: "skill", Description: "Remove AI patterns from writing", Author: "blader", Stars: 543, Source: "registry"},
-		{Name: "claudeception", Version: "1.0.0", Type: "skill", Description: "Extract learnings into reusable skills", Author: "blader", Stars: 421, Source: "registry"},
-		{Name: "test-driven-development", Version: "1.0.0", Type: "skill", Description: "TDD workflow for features and bugfixes", Author: "obra", Stars: 389, Source: "registry"},
-		{Name: "brainstorming", Version: "1.0.0", Type: "skill", Description: "Structured ideation and design exploration", Author: "obra", Stars: 312, Source: "registry"},
-		{Name: "changelog-generator", Version: "1.0.0", Type: "skill", Description: "Generate changelogs from git history", Author: "composio", Stars: 287, Source: "registry"},
-		{Name: "git-worktrees", Version: "1.0.0", Type: "skill", Description: "Work with isolated git worktrees", Author: "obra", Stars: 245, Source: "registry"},
-		{Name: "react-query", Version: "2.1.0", Type: "context", Description: "React Query patterns and best practices", Author: "tunajam", Stars: 1247, Source: "registry"},
-		{Name: "drizzle-orm", Version: "1.0.0", Type: "context", Description: "Drizzle ORM conventions and patterns", Author: "tunajam", Stars: 876, Source: "registry"},
-		{Name: "mcp-builder", Version: "1.0.0", Type: "skill", Description: "Build MCP servers for LLM integrations", Author: "composio", Stars: 534, Source: "registry"},
-		{Name: "youtube-transcript", Version: "1.0.0", Type: "skill", Description: "Fetch and summarize YouTube transcripts", Author: "tapestry", Stars: 423, Source: "registry"},
-	}
-}
-
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/installed"
+	packstore "github.com/tunajam/packs/internal/store"
+)
+
+func GCCmd() *cobra.Command {
+	var dryRunFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete store entries no installed pack uses",
+		Long: `Delete entries in the store (~/.packs/store, config key store.dir) that
+no installed pack links to any more.
+
+Every install records itself against the store entry its files link to,
+and removing or updating the pack drops that record. An entry is only
+deleted when none of the packs recorded against it is still installed
+from it.
+
+FLAGS:
+  -n, --dry-run   Show what would be deleted
+
+EXAMPLES:
+  packs gc
+  packs gc --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGC(dryRunFlag)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&dryRunFlag, "dry-run", "n", false, "Show what would be deleted")
+
+	return cmd
+}
+
+func runGC(dryRun bool) error {
+	store := newPackStore()
+	entries, err := store.Entries()
+	if err != nil {
+		return err
+	}
+
+	// Packs in known directories count even if their record went missing
+	all, err := installedPacks("", "", "")
+	if err != nil {
+		return err
+	}
+	inUse := map[string][]packstore.Ref{}
+	for _, p := range all {
+		if p.Store != "" {
+			inUse[p.Store] = append(inUse[p.Store], storeRef(p.Root, p.Name))
+		}
+	}
+
+	removed := 0
+	var freed int64
+	for _, e := range entries {
+		live := inUse[e.Hash]
+		for _, ref := range e.Refs {
+			if p, err := installed.Read(ref.Root, ref.Name); err == nil && p.Store == e.Hash && !hasRef(live, ref) {
+				live = append(live, ref)
+			}
+		}
+
+		if len(live) > 0 {
+			if !dryRun {
+				if err := store.SetRefs(e.Hash, live); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("  Would delete %s (%s)\n", shortHash(e.Hash), formatBytes(e.Size))
+		} else if err := store.Remove(e.Hash); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", e.Hash, err)
+		}
+		removed++
+		freed += e.Size
+	}
+
+	if dryRun {
+		fmt.Printf("\nWould delete %d of %d store entries, freeing %s\n", removed, len(entries), formatBytes(freed))
+		return nil
+	}
+	fmt.Printf("✓ Deleted %d unused store entries, freed %s\n", removed, formatBytes(freed))
+	if removed < len(entries) {
+		fmt.Printf("  %d entries are still linked from installed packs\n", len(entries)-removed)
+	}
+	return nil
+}
+
+func hasRef(refs []packstore.Ref, ref packstore.Ref) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// shortHash abbreviates a store entry's hash for display
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
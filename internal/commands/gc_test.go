@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/installed"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	packstore "github.com/tunajam/packs/internal/store"
+)
+
+// testHome gives a test its own home directory and project, and a config
+// loaded from them
+func testHome(t *testing.T) (home, project string) {
+	home = t.TempDir()
+	project = filepath.Join(home, "project")
+	if err := os.MkdirAll(filepath.Join(project, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Chdir(project)
+
+	loadedConfig, openedCache = nil, nil
+	t.Cleanup(func() { loadedConfig, openedCache = nil, nil })
+	return home, project
+}
+
+func TestGCPrunesRefs(t *testing.T) {
+	home, _ := testHome(t)
+	store := newPackStore()
+
+	put := func(content string) string {
+		hash, err := store.Put([]packfmt.File{{Path: "SKILL.md", Mode: 0644, Content: []byte(content)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	install := func(root, name, hash string) packstore.Ref {
+		if err := installed.Write(root, &installed.Meta{Name: name, Store: hash}); err != nil {
+			t.Fatal(err)
+		}
+		return storeRef(root, name)
+	}
+	addRefs := func(hash string, refs ...packstore.Ref) {
+		for _, ref := range refs {
+			if err := store.AddRef(hash, ref); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	elsewhere := filepath.Join(home, "elsewhere", "skills")
+	known := agents.Locations()[0].Dir
+
+	// shared: linked from a directory gc doesn't scan, plus refs to a pack
+	// that was removed and one that was reinstalled from another entry
+	shared, reinstalled := put("shared\n"), put("reinstalled\n")
+	live := install(elsewhere, "hello", shared)
+	moved := install(elsewhere, "moved", reinstalled)
+	addRefs(shared, live, storeRef(filepath.Join(home, "gone"), "hello"), moved)
+	addRefs(reinstalled, moved)
+
+	// unrecorded: linked from a known directory whose record went missing
+	unrecorded := put("unrecorded\n")
+	found := install(known, "found", unrecorded)
+
+	// unused: only refs to packs that are gone
+	unused := put("unused\n")
+	addRefs(unused, storeRef(filepath.Join(home, "gone"), "unused"))
+
+	refs := func(hash string) []packstore.Ref {
+		refs, err := store.Refs(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return refs
+	}
+	exists := func(hash string) bool {
+		_, err := os.Stat(filepath.Join(store.Dir(), hash))
+		return err == nil
+	}
+
+	if err := runGC(true); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(unused) || len(refs(shared)) != 3 || len(refs(unrecorded)) != 0 {
+		t.Fatal("dry run changed the store")
+	}
+
+	if err := runGC(false); err != nil {
+		t.Fatal(err)
+	}
+	if got := refs(shared); len(got) != 1 || got[0] != live {
+		t.Errorf("shared refs = %v, want [%v]", got, live)
+	}
+	if got := refs(reinstalled); len(got) != 1 || got[0] != moved {
+		t.Errorf("reinstalled refs = %v, want [%v]", got, moved)
+	}
+	if got := refs(unrecorded); len(got) != 1 || got[0] != found {
+		t.Errorf("unrecorded refs = %v, want [%v]", got, found)
+	}
+	if exists(unused) {
+		t.Error("unused entry kept")
+	}
+	for _, hash := range []string{shared, reinstalled, unrecorded} {
+		if !exists(hash) {
+			t.Errorf("entry %s deleted", shortHash(hash))
+		}
+	}
+}
@@ -2,23 +2,38 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/atomicfile"
 	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/config"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/manifest"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/packref"
+	packstore "github.com/tunajam/packs/internal/store"
 )
 
 func GetCmd() *cobra.Command {
 	var outputFlag string
+	var agentFlag string
+	var scopeFlag string
+	var inlineFlag string
 	var installFlag bool
 	var forceFlag bool
+	var onConflictFlag string
+	var copyFlag bool
+	var saveFlag bool
+	var preFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "get <pack>",
@@ -28,15 +43,54 @@ func GetCmd() *cobra.Command {
 SOURCES:
   packs get commit-message              Registry (packs.sh)
   packs get commit-message@1.0.0        Specific version
+  packs get commit-message@^1.2         Version range (also ~1.2.3, ">=1.0 <2")
   packs get @user/repo/pack             GitHub shorthand
   packs get gh:user/repo/pack           GitHub explicit
+  packs get gh:user/repo/pack@v1.2      GitHub tag, branch or commit
+  packs get git+https://host/repo.git//pack#v1.2
+                                        Any git repo, directory and ref
+  packs get git+ssh://git@host/repo.git Over SSH
+  packs get ./my-pack                   Local directory (also file:./my-pack)
+  packs get ./pack.tgz                  Local archive (.tar.gz, .tgz, .zip)
+  packs get https://host/pack.zip       Archive URL
 
 INSTALLATION:
-  By default, packs installs to your detected agent's skills directory:
-    • Claude Code:  ~/.claude/skills/<pack>/
-    • Clawdbot:     ./skills/<pack>/
-    • Codex:        ~/.codex/skills/<pack>/
-    • Generic:      ~/.packs/skills/<pack>/
+  By default, packs installs for the first agent it detects, in the
+  layout that agent reads:
+    • claude     Claude Code     ~/.claude/skills/<pack>/
+    • clawdbot   Clawdbot        ./skills/<pack>/
+    • codex      Codex           ~/.codex/skills/<pack>/
+    • cursor     Cursor          ./.cursor/rules/<pack>.mdc
+    • copilot    GitHub Copilot  ./.github/instructions/<pack>.instructions.md
+    • gemini     Gemini CLI      ~/.gemini/extensions/<pack>/
+    • aider      Aider           ./conventions/<pack>.md
+    • generic    Generic         ~/.packs/skills/<pack>/
+
+  --agent picks one or more agents instead. The whole pack is installed:
+  SKILL.md plus any scripts, references and assets, with executable bits
+  preserved. Single-file agents get them in <pack>/ next to the file.
+
+  --inline writes the pack's content into a file the agent already reads,
+  like AGENTS.md or CLAUDE.md, between packs:begin and packs:end markers.
+  Updates and removals only touch the lines between the markers.
+
+  Installed files are links into a shared store (~/.packs/store), so a pack
+  installed for several agents is on disk once. Project installs are
+  hardlinked or copied, never symlinked out of the repo. --copy writes
+  plain copies instead.
+
+  Project installs apply the project's overlays for the pack, from
+  .packs/overlays/<pack>/: SKILL.append.md, SKILL.prepend.md and
+  SKILL.replace.md change SKILL.md, and *.patch files are unified diffs.
+
+  When the pack is already there, packs asks what to do: show a diff,
+  overwrite it, keep yours, install the new one next to it as <pack>-new,
+  or merge the two with conflict markers. Without a terminal it fails
+  unless --on-conflict says what to do:
+    skip       Keep what's installed
+    overwrite  Replace it (same as --force)
+    fail       Stop without installing anything
+    backup     Replace it, keeping a copy in ~/.packs/backups/
 
   Use --output to specify a custom path, or pipe to handle manually:
     packs get commit-message | pbcopy    # Copy to clipboard
@@ -44,209 +98,580 @@ INSTALLATION:
 
 FLAGS:
   -o, --output <path>   Install to specific directory
+  -a, --agent <names>   Install for these agents (comma-separated)
+      --inline <file>   Write into a managed block in this file
   -i, --install         Force install (skip stdout, always write to disk)  
   -f, --force           Overwrite existing pack
+      --on-conflict <how> skip, overwrite, fail or backup an existing pack
+      --copy            Copy files instead of linking them to the store
+  -s, --save            Add to packs.yaml and packs.lock
+      --pre             Allow prerelease versions
 
 EXAMPLES:
   packs get commit-message                    # Install from registry
   packs get @anthropics/skills/docx           # Install from GitHub
+  packs get commit-message -a cursor,claude   # Install for two agents
+  packs get react-patterns --inline AGENTS.md # Inline into AGENTS.md
   packs get commit-message -o ./my-skills/    # Custom install path
+  packs get commit-message --on-conflict=backup  # Replace, keeping a copy
+  packs get commit-message@1.0.0 --save       # Install and record in packs.yaml
   packs get commit-message | cat              # Output to stdout (pipe detected)`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGet(args[0], outputFlag, installFlag, forceFlag)
+			return runGet(cmd.Context(), args[0], outputFlag, agentFlag, scopeFlag, inlineFlag, installFlag, forceFlag, onConflictFlag, copyFlag, saveFlag, preFlag)
 		},
 	}
 
 	cmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Install to specific directory")
+	cmd.Flags().StringVarP(&agentFlag, "agent", "a", "", "Install for these agents (comma-separated)")
+	cmd.Flags().StringVar(&scopeFlag, "scope", "", "Install to the project or user scope")
+	cmd.Flags().StringVar(&inlineFlag, "inline", "", "Write into a managed block in this file (e.g. AGENTS.md)")
 	cmd.Flags().BoolVarP(&installFlag, "install", "i", false, "Force install to disk")
 	cmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Overwrite existing pack")
+	cmd.Flags().StringVar(&onConflictFlag, "on-conflict", "", "When the pack exists: skip, overwrite, fail or backup")
+	cmd.Flags().BoolVar(&copyFlag, "copy", false, "Copy files instead of linking them to the store")
+	cmd.Flags().BoolVarP(&saveFlag, "save", "s", false, "Add to packs.yaml and packs.lock")
+	cmd.Flags().BoolVar(&preFlag, "pre", false, "Allow prerelease versions")
 
 	return cmd
 }
 
-func runGet(pack string, outputDir string, install bool, force bool) error {
-	// Normalize @ to gh: 
-	if strings.HasPrefix(pack, "@") {
-		pack = "gh:" + pack[1:]
-	}
-
-	var content string
-	var packName string
-	var err error
-
-	// Fetch content based on source
-	if strings.HasPrefix(pack, "gh:") {
-		ref := pack[3:] // Strip "gh:" prefix
-		content, packName, err = getFromGitHub(ref)
-	} else {
-		content, packName, err = getFromRegistry(pack)
+func runGet(ctx context.Context, ref string, outputDir string, agent string, scope string, inline string, install bool, force bool, onConflict string, copyFiles bool, save bool, pre bool) error {
+	onConflict, err := conflictMode(onConflict, force)
+	if err != nil {
+		return err
 	}
 
+	r, err := packref.Parse(ref)
 	if err != nil {
 		return err
 	}
+	fetched, err := fetchPack(ctx, r, pre)
+	if err != nil {
+		return whenInterrupted(ctx, err)
+	}
+	fetched.Locked.Spec = saveSpec(r, fetched.Locked)
+	locked := fetched.Locked
 
 	// Determine output mode
 	isPiped := !isTerminal()
 	
-	if isPiped && outputDir == "" && !install {
+	if isPiped && outputDir == "" && agent == "" && scope == "" && inline == "" && !install && !save {
 		// Piped output - just print content
-		fmt.Print(content)
+		fmt.Print(fetched.Content())
 		return nil
 	}
 
 	// Install mode
-	installPath := outputDir
-	if installPath == "" {
-		installPath = detectAgentSkillsDir()
+	locs, err := resolveLocations(agent, scope, outputDir, inline)
+	if err != nil {
+		return err
 	}
 
-	// Create pack directory
-	packDir := filepath.Join(installPath, packName)
-	
-	// Check if exists
-	if _, err := os.Stat(packDir); err == nil && !force {
-		return fmt.Errorf("pack already exists: %s\nUse --force to overwrite", packDir)
+	// Settle every conflict first, so one that fails installs nothing
+	var plans []*installPlan
+	for _, loc := range locs {
+		plan, err := planInstall(ctx, loc, fetched, onConflict, copyFiles)
+		if err != nil {
+			return err
+		}
+		if plan != nil {
+			plans = append(plans, plan)
+		}
 	}
 
-	// Create directory
-	if err := os.MkdirAll(packDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	// Every location gets the pack, or none does
+	tx := newInstallTx(ctx)
+	defer tx.Rollback()
+	var done []*installed.Pack
+	for _, plan := range plans {
+		p, err := installTarget(tx, plan.loc, plan.fetched, plan.opts)
+		if err != nil {
+			return err
+		}
+		done = append(done, p)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for i, p := range done {
+		printGot(p, plans[i])
 	}
 
-	// Write SKILL.md
-	skillPath := filepath.Join(packDir, "SKILL.md")
-	if err := os.WriteFile(skillPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write skill: %w", err)
+	if save {
+		return savePack(locked, locked.Spec)
+	}
+	return nil
+}
+
+// fetchedPack is a fetched pack's files and where they came from
+type fetchedPack struct {
+	Files       []packfmt.File
+	Type        string
+	Description string
+	Locked      manifest.LockedPack
+	Pack        string // name at the source, when installed under another
+}
+
+// Content returns the main content file, which is what gets piped
+func (f *fetchedPack) Content() string {
+	if main := packfmt.MainFile(f.Files, f.Type); main != nil {
+		return string(main.Content)
+	}
+	return ""
+}
+
+// agentPack is the pack as agent targets lay it out
+func (f *fetchedPack) agentPack() *agents.Pack {
+	return &agents.Pack{
+		Name:        f.Locked.Name,
+		Version:     f.Locked.Version,
+		Type:        f.Type,
+		Description: f.Description,
+		Files:       f.Files,
+	}
+}
+
+// fetchPack fetches the pack a reference points to: from a registry,
+// GitHub, a git repo, a directory, or an archive file or URL
+func fetchPack(ctx context.Context, r packref.PackRef, pre bool) (*fetchedPack, error) {
+	var fetched *fetchedPack
+	var err error
+	switch r.Kind {
+	case packref.GitHub:
+		if offlineFlag {
+			return nil, fmt.Errorf("GitHub packs are not available offline: %s", r)
+		}
+		fetched, err = fetchFromGitHub(ctx, r)
+	case packref.Git:
+		if offlineFlag {
+			return nil, fmt.Errorf("git packs are not available offline: %s", r)
+		}
+		fetched, err = fetchFromGit(ctx, r)
+	case packref.Local:
+		fetched, err = fetchFromDir(r)
+	case packref.Archive:
+		fetched, err = fetchFromArchive(ctx, r)
+	default:
+		fetched, err = fetchFromRegistry(ctx, r.Name, r.Version, pre)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPackName(fetched.Locked.Name, r.String()); err != nil {
+		return nil, err
 	}
+	return fetched, nil
+}
 
-	fmt.Printf("✓ Installed %s to %s\n", packName, packDir)
+// checkPackName rejects a name from a pack.yaml, registry, manifest or
+// lockfile that would install outside its agent directory
+func checkPackName(name, source string) error {
+	if !packfmt.ValidName(name) {
+		return fmt.Errorf("%w %q in %s\nPack names are one path element, without spaces or a leading dot", packfmt.ErrInvalidName, name, source)
+	}
 	return nil
 }
 
-func getFromGitHub(ref string) (content string, name string, err error) {
-	// Parse: user/repo or user/repo/path/to/pack
-	parts := strings.SplitN(ref, "/", 3)
-	if len(parts) < 2 {
-		return "", "", fmt.Errorf("invalid GitHub reference: %s\nExpected format: @user/repo or @user/repo/path", ref)
+// installOpts are how installTarget treats a pack that's already there
+type installOpts struct {
+	force  bool              // replace it
+	merged map[string][]byte // content to write instead of the pack's, by path; nil leaves a file out
+	backup string            // directory to keep a copy of what's replaced in
+	copy   bool              // write plain files instead of store links
+}
+
+// installTarget lays a pack out for an agent target, stages it with its
+// permissions and swaps it into the target's directory as part of tx, and
+// records what it wrote. Files an earlier install wrote that the new layout
+// drops go; files the user added stay.
+func installTarget(tx *installTx, loc agents.Location, fetched *fetchedPack, opts installOpts) (*installed.Pack, error) {
+	lp := fetched.Locked
+	root := loc.Dir
+
+	files, overlays, err := layoutFor(loc, fetched)
+	if err != nil {
+		return nil, err
 	}
 
-	user := parts[0]
-	repo := parts[1]
-	path := ""
-	if len(parts) > 2 {
-		path = parts[2]
+	prev, err := installed.Read(root, lp.Name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
 	}
-	
-	// Extract pack name from path or repo name
-	if path != "" {
-		name = filepath.Base(path)
-	} else {
-		name = repo
+
+	if !opts.force {
+		if err := checkExisting(loc, lp.Name, files); err != nil {
+			return nil, err
+		}
 	}
 
-	// Try content files in order: SKILL.md, CONTEXT.md, PROMPT.md
-	contentFiles := []string{"SKILL.md", "CONTEXT.md", "PROMPT.md"}
-	
-	// Try gh CLI first (handles auth, private repos)
-	if ghInstalled() {
-		for _, file := range contentFiles {
-			content, err = ghGetContent(user, repo, path, file)
-			if err == nil {
-				return content, name, nil
-			}
+	meta := &installed.Meta{
+		Name:        lp.Name,
+		Pack:        fetched.Pack,
+		Version:     lp.Version,
+		Spec:        installedRef(lp.Spec, loc.Scope),
+		Type:        fetched.Type,
+		Source:      lp.Source,
+		Ref:         installedRef(lp.Ref, loc.Scope),
+		Registry:    lp.Registry,
+		Commit:      lp.Commit,
+		ContentHash: lp.ContentHash,
+		Agent:       loc.Target.Name(),
+		Scope:       string(loc.Scope),
+		InstalledAt: time.Now(),
+		Overlays:    overlays,
+	}
+	stage, err := tx.stage(root)
+	if err != nil {
+		return nil, err
+	}
+	if opts.backup != "" {
+		if err := backUp(tx, root, files, opts.backup); err != nil {
+			return nil, err
+		}
+	}
+	if agents.IsInline(loc.Target) {
+		if err := installBlocks(tx, stage, root, meta, files, prev, opts.merged); err != nil {
+			return nil, err
+		}
+		return installed.Read(root, lp.Name)
+	}
+
+	store := newPackStore()
+	links := storeLinks(loc, opts.copy)
+	if len(links) > 0 {
+		if meta.Store, err = store.Put(files); err != nil {
+			return nil, fmt.Errorf("failed to add %s to %s: %w", lp.Name, store.Dir(), err)
 		}
 	}
 
-	// Fallback: raw.githubusercontent.com (public repos only)
-	for _, file := range contentFiles {
-		var url string
-		if path != "" {
-			url = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/main/%s/%s",
-				user, repo, path, file)
+	// Stage the new files, noting every top-level entry this install or the
+	// last one touches
+	ours := map[string]bool{}
+	var tops []string
+	addTop := func(rel string) {
+		top, _, _ := strings.Cut(rel, "/")
+		for _, t := range tops {
+			if t == top {
+				return
+			}
+		}
+		tops = append(tops, top)
+	}
+	meta.Link = string(packstore.Copy)
+	for _, f := range files {
+		if !packfmt.ValidPath(f.Path) {
+			return nil, fmt.Errorf("%w: %q", packfmt.ErrUnsafePath, f.Path)
+		}
+		mode := packfmt.NormalizeMode(f.Mode)
+		dst := filepath.Join(stage, filepath.FromSlash(f.Path))
+		merged, isMerged := opts.merged[f.Path]
+		if isMerged && merged == nil {
+			// Deleted locally, and it stays that way
+		} else if isMerged {
+			// Merges hold the user's edits, so they aren't linked
+			err = atomicfile.Write(dst, merged, os.FileMode(mode))
+		} else if meta.Store == "" {
+			err = atomicfile.Write(dst, f.Content, os.FileMode(mode))
 		} else {
-			url = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/main/%s",
-				user, repo, file)
+			var link packstore.Link
+			link, err = store.Link(meta.Store, f, dst, links...)
+			if link != packstore.Copy {
+				meta.Link = string(link)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+		meta.Outputs = append(meta.Outputs, packfmt.File{Path: f.Path, Mode: mode, SHA256: installed.Hash(f.Content)})
+		ours[f.Path] = true
+		addTop(f.Path)
+	}
+	if prev != nil {
+		for _, rel := range prev.Paths() {
+			ours[rel] = true
+			addTop(rel)
 		}
+	}
+
+	// Nothing could be linked, so nothing refers to the store entry
+	if meta.Link == string(packstore.Copy) {
+		meta.Store = ""
+	}
 
-		resp, err := http.Get(url)
+	// Files the user added next to the pack move over with it, and stale
+	// ones of the last install are left behind
+	for _, top := range tops {
+		err := carryOver(filepath.Join(root, top), filepath.Join(stage, top), func(rel string) bool {
+			return !ours[path.Join(top, rel)]
+		})
 		if err != nil {
-			continue
+			return nil, err
 		}
-		defer resp.Body.Close()
+	}
 
-		if resp.StatusCode == 200 {
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				continue
-			}
-			return string(body), name, nil
+	for _, top := range tops {
+		staged := filepath.Join(stage, top)
+		if _, err := os.Lstat(staged); err != nil {
+			staged = ""
+		}
+		if err := tx.replace(stage, filepath.Join(root, top), staged); err != nil {
+			return nil, fmt.Errorf("failed to install %s: %w", top, err)
+		}
+	}
+	if err := writeBase(tx, root, lp.Name, files); err != nil {
+		return nil, err
+	}
+	if err := writeMeta(tx, stage, root, meta); err != nil {
+		return nil, err
+	}
+
+	ref := storeRef(root, lp.Name)
+	tx.whenCommitted(func() {
+		if meta.Store != "" {
+			store.AddRef(meta.Store, ref)
 		}
+		if prev != nil && prev.Store != "" && prev.Store != meta.Store {
+			store.RemoveRef(prev.Store, ref)
+		}
+	})
+	return installed.Read(root, lp.Name)
+}
+
+// writeMeta stages a pack's metadata and swaps it in with the pack
+func writeMeta(tx *installTx, stage, root string, meta *installed.Meta) error {
+	if err := installed.Write(stage, meta); err != nil {
+		return fmt.Errorf("failed to write %s: %w", installed.MetaPath(root, meta.Name), err)
 	}
+	return tx.replace(stage, installed.MetaPath(root, meta.Name), installed.MetaPath(stage, meta.Name))
+}
 
-	return "", "", fmt.Errorf("pack not found: %s\nTried: SKILL.md, CONTEXT.md, PROMPT.md", ref)
+// writeBase stages the upstream copy of a pack's files, which later
+// updates merge local edits against, and swaps it in with the pack
+func writeBase(tx *installTx, root, name string, files []packfmt.File) error {
+	stage, err := tx.stageIn(installed.StateDir())
+	if err != nil {
+		return err
+	}
+	staged := filepath.Join(stage, "base")
+	for _, f := range files {
+		if err := atomicfile.Write(filepath.Join(staged, filepath.FromSlash(f.Path)), f.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", installed.BasePath(root, name), err)
+		}
+	}
+	if len(files) == 0 {
+		staged = ""
+	}
+	return tx.replace(stage, installed.BasePath(root, name), staged)
 }
 
-func getFromRegistry(pack string) (content string, name string, err error) {
-	// Parse version if present: pack@version
-	name = pack
-	version := ""
-	if idx := strings.Index(pack, "@"); idx != -1 {
-		name = pack[:idx]
-		version = pack[idx+1:]
+// storeLinks picks how installed files point at the store, best first.
+// Project installs get committed, so they're never symlinked to a path in
+// someone's home directory. None means copy.
+func storeLinks(loc agents.Location, copyFiles bool) []packstore.Link {
+	if copyFiles {
+		return nil
+	}
+	if loc.Scope == agents.ScopeProject {
+		return []packstore.Link{packstore.Hardlink}
 	}
+	return []packstore.Link{packstore.Hardlink, packstore.Symlink}
+}
 
-	// Try API first
-	client := api.New()
-	ctx := context.Background()
+// storeRef is how the store records a pack installed in an agent directory
+func storeRef(root, name string) packstore.Ref {
+	abs, _ := filepath.Abs(root)
+	return packstore.Ref{Root: abs, Name: name}
+}
 
-	p, err := client.Get(ctx, name, version)
-	if err == nil && p.Content != "" {
-		// Send telemetry
-		osName, arch := GetRuntimeInfo()
-		client.Telemetry(ctx, name, "registry", p.Version, "1.0.0", osName, arch)
-		return p.Content, name, nil
+// checkExisting fails when a pack's files, directories or managed blocks
+// are already in place
+func checkExisting(loc agents.Location, name string, files []packfmt.File) error {
+	root := loc.Dir
+	if agents.IsInline(loc.Target) {
+		return checkBlocks(root, name, files)
+	}
+	for _, f := range files {
+		top, _, _ := strings.Cut(f.Path, "/")
+		if path := filepath.Join(root, top); fileExists(path) || dirExists(path) {
+			return fmt.Errorf("%w: %s\nUse --force to overwrite, or --on-conflict=backup to keep a copy", errPackExists, path)
+		}
+	}
+	return nil
+}
+
+// fetchFromRegistry resolves a version constraint against the registries
+// and fetches the selected version
+func fetchFromRegistry(ctx context.Context, name, constraint string, pre bool) (*fetchedPack, error) {
+	// Try configured registries first, in priority order
+	registries := newRegistries("")
+
+	var p *api.Pack
+	version, err := api.ResolveVersion(ctx, registries, name, constraint, pre)
+	if api.IsResolveError(err) {
+		return nil, err
+	}
+	if err == nil {
+		p, err = registries.Get(ctx, name, version)
+	}
+	if errors.Is(err, api.ErrHashMismatch) || errors.Is(err, packfmt.ErrUnsafePath) {
+		return nil, fmt.Errorf("refusing to install %s: %w\n\nThe download is corrupted or was tampered with. Nothing was written.", name, err)
+	}
+	if err == nil && len(p.FileSet()) > 0 {
+		if err := checkSignature(registrySubject(p)); err != nil {
+			return nil, err
+		}
+
+		// Send telemetry to the registry that served the pack
+		if sink, ok := registries.Store(p.Registry).(api.TelemetrySink); ok && loadConfig().Bool("telemetry") {
+			osName, arch := GetRuntimeInfo()
+			sink.Telemetry(ctx, name, "registry", p.Version, "1.0.0", osName, arch)
+		}
+		return &fetchedPack{
+			Files:       p.FileSet(),
+			Type:        p.Type,
+			Description: p.Description,
+			Locked: manifest.LockedPack{
+				Name:        name,
+				Version:     p.Version,
+				Source:      "registry",
+				Registry:    p.Registry,
+				ContentHash: packfmt.Digest(p.FileSet()),
+			},
+		}, nil
 	}
 
-	// Fallback: try GitHub via packs-registry
-	registryRef := fmt.Sprintf("tunajam/packs-registry/skills/%s", name)
-	content, _, err = getFromGitHub(registryRef)
+	ref := packLabel(name, constraint)
+	if offlineFlag {
+		return nil, fmt.Errorf("pack not in cache: %s\n\nRun 'packs get %s' online first, or drop --offline", ref, ref)
+	}
+	if err == nil {
+		err = fmt.Errorf("%w: %s", api.ErrNotFound, ref)
+	}
+	if !errors.Is(err, api.ErrNotFound) {
+		return nil, fmt.Errorf("couldn't fetch %s: %w\n\nCheck that the registry is reachable and try again", ref, err)
+	}
+
+	// Fallback: try GitHub via packs-registry. It only has the latest
+	// version, so a pinned version or range never falls back.
+	if !api.IsLatest(constraint) {
+		return nil, fmt.Errorf("%w\n\nRun 'packs info %s' to see its versions", err, name)
+	}
+	registryRef := packref.PackRef{Kind: packref.GitHub, Repo: "tunajam/packs-registry", Path: "skills/" + name}
+	fetched, err := fetchFromGitHub(ctx, registryRef)
 	if err != nil {
-		return "", "", fmt.Errorf("pack not found in registry: %s\n\nTry GitHub direct: packs get @user/repo/%s", name, name)
+		return nil, fmt.Errorf("pack not found in registry: %s\n\nTry GitHub direct: packs get @user/repo/%s", name, name)
 	}
 
-	return content, name, nil
+	return fetched, nil
 }
 
-func detectAgentSkillsDir() string {
-	home, _ := os.UserHomeDir()
-	
-	// Check for Claude Code
-	claudeDir := filepath.Join(home, ".claude", "skills")
-	if dirExists(filepath.Join(home, ".claude")) {
-		return claudeDir
+// resolveLocations picks where to install: a managed block in the --inline
+// file, each --agent in the --scope or its default scope, or the detected
+// agent. --output overrides the directory.
+func resolveLocations(agent, scopeFlag, outputDir, inline string) ([]agents.Location, error) {
+	scope, err := agents.ParseScope(scopeFlag)
+	if err != nil {
+		return nil, err
+	}
+	if inline != "" {
+		if agent != "" || scope != "" || outputDir != "" {
+			return nil, fmt.Errorf("--inline can't be combined with --agent, --scope or --output\nThe file given decides where the pack goes")
+		}
+		return []agents.Location{agents.InlineFile(inline)}, nil
+	}
+	if outputDir != "" && scope != "" {
+		return nil, fmt.Errorf("--output and --scope can't be combined\n--output installs to exactly the directory given")
 	}
 
-	// Check for Clawdbot (workspace skills/)
-	if dirExists("skills") || fileExists("AGENTS.md") || fileExists("SOUL.md") {
-		return "skills"
+	if agent == "" {
+		if outputDir != "" {
+			return []agents.Location{agents.Directory("generic", "", outputDir)}, nil
+		}
+		return []agents.Location{detectLocation(scope)}, nil
+	}
+	if outputDir != "" && strings.Contains(agent, ",") {
+		return nil, fmt.Errorf("--output takes a single --agent\nDrop --output to install to each agent's own directory")
 	}
 
-	// Check for Codex
-	codexDir := filepath.Join(home, ".codex", "skills")
-	if dirExists(filepath.Join(home, ".codex")) {
-		return codexDir
+	var locs []agents.Location
+	seen := map[string]bool{}
+	for _, name := range strings.Split(agent, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		t, err := agents.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		if outputDir != "" {
+			locs = append(locs, agents.Location{Target: t, Dir: outputDir})
+			continue
+		}
+		loc, err := agents.Locate(t, scope)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, loc)
 	}
+	return locs, nil
+}
 
-	// Check for Cursor
-	cursorDir := filepath.Join(home, ".cursor", "skills")
-	if dirExists(filepath.Join(home, ".cursor")) {
-		return cursorDir
+// configuredLocation is the skills_dir from config, if set, in the scope of
+// the config file that set it
+func configuredLocation() (agents.Location, bool) {
+	cfg := loadConfig()
+	dir := cfg.Path("skills_dir")
+	if dir == "" {
+		return agents.Location{}, false
+	}
+	scope := agents.ScopeUser
+	if v, _ := cfg.Lookup("skills_dir"); v.Source == config.SourceProject {
+		scope = agents.ScopeProject
 	}
+	return agents.Directory("custom", scope, dir), true
+}
 
-	// Default: ~/.packs/skills
-	return filepath.Join(home, ".packs", "skills")
+// detectLocation is where packs go by default. An explicit skills_dir
+// (config, project config or PACKS_SKILLS_DIR) wins over detection unless
+// it's in another scope.
+func detectLocation(scope agents.Scope) agents.Location {
+	if loc, ok := configuredLocation(); ok && (scope == "" || scope == loc.Scope) {
+		return loc
+	}
+	return agents.Detect(scope)
+}
+
+// knownLocations lists everywhere packs installs to: the configured
+// skills_dir first, then every built-in agent in each of its scopes, then
+// the directories of files that hold managed blocks
+func knownLocations() []agents.Location {
+	var locs []agents.Location
+	if loc, ok := configuredLocation(); ok {
+		locs = append(locs, loc)
+	}
+	locs = append(locs, agents.Locations()...)
+	return append(locs, agents.InlineLocations()...)
+}
+
+// locationFor returns where an installed pack was laid out. Packs from
+// older versions and --output installs are skill directories.
+func locationFor(p *installed.Pack) agents.Location {
+	if p.Managed {
+		return agents.Inline(p.Root, agents.Scope(p.Scope), p.Paths()...)
+	}
+	root, _ := filepath.Abs(p.Root)
+	for _, loc := range knownLocations() {
+		if dir, _ := filepath.Abs(loc.Dir); loc.Target.Name() == p.Agent && dir == root {
+			return loc
+		}
+	}
+	if t, err := agents.Lookup(p.Agent); err == nil {
+		return agents.Location{Target: t, Scope: agents.Scope(p.Scope), Dir: p.Root}
+	}
+	return agents.Directory(p.Agent, agents.Scope(p.Scope), p.Root)
 }
 
 func isTerminal() bool {
@@ -254,6 +679,12 @@ func isTerminal() bool {
 	return (fi.Mode() & os.ModeCharDevice) != 0
 }
 
+// isInteractive reports whether there's someone at a terminal to ask
+func isInteractive() bool {
+	fi, _ := os.Stdin.Stat()
+	return isTerminal() && (fi.Mode()&os.ModeCharDevice) != 0
+}
+
 func dirExists(path string) bool {
 	info, err := os.Stat(path)
 	return err == nil && info.IsDir()
@@ -264,29 +695,6 @@ func fileExists(path string) bool {
 	return err == nil && !info.IsDir()
 }
 
-func ghInstalled() bool {
-	_, err := exec.LookPath("gh")
-	return err == nil
-}
-
-func ghGetContent(user, repo, path, file string) (string, error) {
-	var apiPath string
-	if path != "" {
-		apiPath = fmt.Sprintf("/repos/%s/%s/contents/%s/%s", user, repo, path, file)
-	} else {
-		apiPath = fmt.Sprintf("/repos/%s/%s/contents/%s", user, repo, file)
-	}
-	cmd := exec.Command("gh", "api", apiPath,
-		"-H", "Accept: application/vnd.github.raw+json")
-
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-
-	return string(output), nil
-}
-
 // GetRuntimeInfo returns OS/arch for telemetry
 func GetRuntimeInfo() (string, string) {
 	return runtime.GOOS, runtime.GOARCH
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tunajam/packs/internal/packref"
+)
+
+// commitHash matches a full git commit SHA
+var commitHash = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// fetchFromGit fetches a pack from any git repo with the git CLI, at the
+// commit its branch, tag or default branch points to
+func fetchFromGit(ctx context.Context, r packref.PackRef) (*fetchedPack, error) {
+	tmp, err := os.MkdirTemp("", "packs-git-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	rev := r.Rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+	if _, err := git(ctx, tmp, "init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, tmp, "fetch", "-q", "--depth", "1", "--end-of-options", r.Repo, rev); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", r, err)
+	}
+	commit, err := git(ctx, tmp, "rev-parse", "FETCH_HEAD^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, tmp, "checkout", "-q", "--detach", commit); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(tmp, filepath.FromSlash(r.Path))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("no directory %s in %s at %s", r.Path, r.Repo, shortCommit(commit))
+	}
+	fetched, err := readPackDir(r, dir, r.BaseName())
+	if err != nil {
+		return nil, err
+	}
+	// The lock pins the commit, and the ref stays where it came from
+	src := r
+	src.Rev = ""
+	fetched.Locked.Ref = src.String()
+	fetched.Locked.Commit = commit
+	return fetched, nil
+}
+
+// resolveGitCommit asks a repo which commit a reference's branch or tag,
+// or its default branch, points to
+func resolveGitCommit(ctx context.Context, r packref.PackRef) (string, error) {
+	if commitHash.MatchString(r.Rev) {
+		return r.Rev, nil
+	}
+	rev := r.Rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+	out, err := git(ctx, "", "ls-remote", "--end-of-options", r.Repo, rev, rev+"^{}")
+	if err != nil {
+		return "", fmt.Errorf("failed to reach %s: %w", r.Repo, err)
+	}
+
+	// Annotated tags list the commit they point to as <tag>^{}
+	commit := ""
+	for _, line := range strings.Split(out, "\n") {
+		sha, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if commit == "" || strings.HasSuffix(name, "^{}") {
+			commit = sha
+		}
+	}
+	if commit == "" {
+		return "", fmt.Errorf("no branch or tag %s in %s", r.Rev, r.Repo)
+	}
+	if !commitHash.MatchString(commit) {
+		return "", fmt.Errorf("%s answered with an invalid commit %q", r.Repo, commit)
+	}
+	return commit, nil
+}
+
+// git runs a git command without prompting for credentials and returns
+// its trimmed output, or its error output as the error
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", fmt.Errorf("git is not installed\nInstall git to use git+ references")
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
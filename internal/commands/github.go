@@ -0,0 +1,418 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tunajam/packs/internal/archive"
+	"github.com/tunajam/packs/internal/manifest"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/packref"
+	"github.com/tunajam/packs/internal/signing"
+)
+
+// githubSource is a GitHub user/repo[/path][@ref] reference
+type githubSource struct {
+	User string
+	Repo string
+	Path string
+	Ref  string // branch, tag or commit; empty is the default branch
+}
+
+// githubSourceOf splits a GitHub pack reference into its parts
+func githubSourceOf(r packref.PackRef) githubSource {
+	user, repo, _ := strings.Cut(r.Repo, "/")
+	return githubSource{User: user, Repo: repo, Path: r.Path, Ref: r.Rev}
+}
+
+// Name is the pack name: the last path element, or the repo name
+func (s githubSource) Name() string {
+	if s.Path != "" {
+		return filepath.Base(s.Path)
+	}
+	return s.Repo
+}
+
+// String returns user/repo[/path], without the ref
+func (s githubSource) String() string {
+	if s.Path != "" {
+		return s.User + "/" + s.Repo + "/" + s.Path
+	}
+	return s.User + "/" + s.Repo
+}
+
+// fetchFromGitHub fetches a pack from GitHub. The ref is resolved to a commit
+// first, so the files and the recorded commit always match; a pack whose
+// commit can't be resolved isn't fetched.
+func fetchFromGitHub(ctx context.Context, r packref.PackRef) (*fetchedPack, error) {
+	src := githubSourceOf(r)
+	commit, err := resolveGitHubCommit(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("%w\nCheck that the repo and ref exist, or run 'packs login' for private repos and rate limits", err)
+	}
+	src.Ref = commit
+
+	files, signature, err := getFromGitHub(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignature(githubSubject(src, files, signature)); err != nil {
+		return nil, err
+	}
+
+	// The lock pins the commit, and the ref stays where it came from
+	ref := r
+	ref.Rev = ""
+	return &fetchedPack{
+		Files: files,
+		Type:  filesPackType(files),
+		Locked: manifest.LockedPack{
+			Name:        src.Name(),
+			Source:      "github",
+			Ref:         ref.String(),
+			Commit:      commit,
+			ContentHash: packfmt.Digest(files),
+		},
+	}, nil
+}
+
+// maxPackFiles caps how many files a pack fetched from a repo or a
+// directory can have, so pointing packs at the root of a large repo fails
+// fast
+const maxPackFiles = 200
+
+// getFromGitHub fetches every file of a GitHub pack, and its pack.sig if
+// it has one: from the repo's tarball in one request, or file by file when
+// the archive endpoint isn't reachable. When the repo tree can't be listed
+// either, it falls back to the pack's content file alone.
+func getFromGitHub(ctx context.Context, src githubSource) ([]packfmt.File, []byte, error) {
+	contents, err := getGitHubArchive(ctx, src)
+	if err == nil {
+		return contents.Files, contents.Signature, nil
+	}
+	// A hostile archive is an answer, not an outage. One that's too big is
+	// the whole repo, which the pack directory's files may still fit in.
+	if errors.Is(err, archive.ErrUnsafe) {
+		return nil, nil, fmt.Errorf("%s: %w", src, err)
+	}
+
+	var files []packfmt.File
+	entries, err := listGitHubTree(ctx, src)
+	if err != nil || len(entries) == 0 {
+		if files, err = getGitHubContentFile(ctx, src); err != nil {
+			return nil, nil, err
+		}
+		return files, githubSignature(ctx, src), nil
+	}
+	if len(entries) > maxPackFiles {
+		return nil, nil, fmt.Errorf("%s has %d files, more than the %d a pack can have\nPoint at the pack directory: @user/repo/path/to/pack", src, len(entries), maxPackFiles)
+	}
+
+	for _, e := range entries {
+		content, err := getGitHubFile(ctx, src, e.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetching %s/%s: %w", src, e.Path, err)
+		}
+		files = append(files, packfmt.File{Path: e.Path, Mode: e.Mode, Content: []byte(content)})
+	}
+
+	if packfmt.MainFile(files, "") == nil {
+		return nil, nil, fmt.Errorf("pack not found: %s\nTried: SKILL.md, CONTEXT.md, PROMPT.md", src)
+	}
+	return files, githubSignature(ctx, src), nil
+}
+
+// githubSignature fetches pack.sig for files fetched one by one. It has to
+// come from the same commit as they did, so there's none unless src is
+// pinned to a commit.
+func githubSignature(ctx context.Context, src githubSource) []byte {
+	if !commitHash.MatchString(src.Ref) {
+		return nil
+	}
+	data, err := getGitHubFile(ctx, src, signing.SigFile)
+	if err != nil {
+		return nil
+	}
+	return []byte(data)
+}
+
+// getGitHubArchive fetches a GitHub pack from the repository tarball at the
+// pack's ref, keeping only the files under the pack directory and its
+// pack.sig
+func getGitHubArchive(ctx context.Context, src githubSource) (*archive.Contents, error) {
+	gitRef := src.Ref
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+	data, err := githubAPI(ctx, fmt.Sprintf("/repos/%s/%s/tarball/%s", src.User, src.Repo, gitRef), "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+
+	limits := archive.DefaultLimits
+	limits.MaxFiles = maxPackFiles
+	contents, err := archive.Extract(data, archive.Options{Dir: src.Path, StripTop: true, Limits: limits})
+	if err != nil {
+		return nil, err
+	}
+	if packfmt.MainFile(contents.Files, "") == nil {
+		return nil, fmt.Errorf("pack not found: %s\nTried: SKILL.md, CONTEXT.md, PROMPT.md", src)
+	}
+	return contents, nil
+}
+
+// getGitHubContentFile fetches just the content file: SKILL.md, CONTEXT.md
+// or PROMPT.md, in that order
+func getGitHubContentFile(ctx context.Context, src githubSource) ([]packfmt.File, error) {
+	for _, file := range packfmt.ContentFiles {
+		content, err := getGitHubFile(ctx, src, file)
+		if err == nil {
+			return []packfmt.File{{Path: file, Mode: 0644, Content: []byte(content)}}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("pack not found: %s\nTried: SKILL.md, CONTEXT.md, PROMPT.md", src)
+}
+
+// filesPackType derives the pack type from the content file a pack ships,
+// for GitHub packs and archives without registry metadata to ask
+func filesPackType(files []packfmt.File) string {
+	main := packfmt.MainFile(files, "")
+	if main == nil {
+		return ""
+	}
+	switch main.Path {
+	case "CONTEXT.md":
+		return "context"
+	case "PROMPT.md":
+		return "prompt"
+	default:
+		return "skill"
+	}
+}
+
+// githubTreeEntry is a file in a pack directory on GitHub, relative to the
+// pack root
+type githubTreeEntry struct {
+	Path string
+	Mode uint32
+}
+
+// listGitHubTree lists the files of a GitHub pack directory with the git
+// trees API. Dotfiles, symlinks, submodules and nested packs are skipped.
+func listGitHubTree(ctx context.Context, src githubSource) ([]githubTreeEntry, error) {
+	gitRef := src.Ref
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+	data, err := githubAPI(ctx, fmt.Sprintf("/repos/%s/%s/git/trees/%s?recursive=1", src.User, src.Repo, gitRef), "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Mode string `json:"mode"`
+			Type string `json:"type"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	if tree.Truncated {
+		return nil, fmt.Errorf("tree of %s is too large to list", src)
+	}
+
+	prefix := ""
+	if src.Path != "" {
+		prefix = src.Path + "/"
+	}
+
+	// Subdirectories with their own pack.yaml are separate packs
+	packDirs := map[string]bool{}
+	for _, e := range tree.Tree {
+		if e.Type == "blob" && strings.HasPrefix(e.Path, prefix) && path.Base(e.Path) == packfmt.MetaFile {
+			packDirs[path.Dir(strings.TrimPrefix(e.Path, prefix))] = true
+		}
+	}
+
+	var entries []githubTreeEntry
+	for _, e := range tree.Tree {
+		if e.Type != "blob" || e.Mode == "120000" || !strings.HasPrefix(e.Path, prefix) {
+			continue
+		}
+		rel := strings.TrimPrefix(e.Path, prefix)
+		if !packfmt.Included(rel) || !packfmt.ValidPath(rel) || packfmt.Nested(rel, packDirs) {
+			continue
+		}
+		mode := uint32(0644)
+		if e.Mode == "100755" {
+			mode = 0755
+		}
+		entries = append(entries, githubTreeEntry{Path: rel, Mode: mode})
+	}
+	return entries, nil
+}
+
+// getGitHubFile fetches one file of a GitHub pack
+func getGitHubFile(ctx context.Context, src githubSource, file string) (string, error) {
+	// Try gh CLI first (handles auth, private repos)
+	if ghInstalled() {
+		if content, err := ghGetContent(ctx, src, file); err == nil {
+			return content, nil
+		}
+	}
+
+	// Fallback: raw.githubusercontent.com (public repos only)
+	gitRef := src.Ref
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+	var url string
+	if src.Path != "" {
+		url = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s/%s",
+			src.User, src.Repo, gitRef, src.Path, file)
+	} else {
+		url = fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
+			src.User, src.Repo, gitRef, file)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("%s/%s: %s", src, file, resp.Status)
+	}
+	body, err := archive.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// resolveGitHubCommit resolves a branch, tag or the default branch to a
+// commit SHA
+func resolveGitHubCommit(ctx context.Context, src githubSource) (string, error) {
+	gitRef := src.Ref
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+	sha, err := githubAPI(ctx, fmt.Sprintf("/repos/%s/%s/commits/%s", src.User, src.Repo, gitRef), "application/vnd.github.sha")
+	if err != nil {
+		return "", fmt.Errorf("resolving %s@%s: %w", src, gitRef, err)
+	}
+
+	commit := strings.TrimSpace(string(sha))
+	if len(commit) != 40 {
+		return "", fmt.Errorf("resolving %s@%s: unexpected response", src, gitRef)
+	}
+	return commit, nil
+}
+
+// listGitHubTags lists a repository's most recent tags
+func listGitHubTags(ctx context.Context, src githubSource) ([]string, error) {
+	data, err := githubAPI(ctx, fmt.Sprintf("/repos/%s/%s/tags?per_page=100", src.User, src.Repo), "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return names, nil
+}
+
+// githubAPI calls the GitHub REST API through the gh CLI when it's installed
+// (handles auth, private repos), or anonymously over HTTPS otherwise.
+// Responses over archive.MaxSize fail with archive.ErrTooLarge.
+func githubAPI(ctx context.Context, apiPath, accept string) ([]byte, error) {
+	if ghInstalled() {
+		return ghAPI(ctx, apiPath, "-H", "Accept: "+accept)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com"+apiPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s: %s", apiPath, resp.Status)
+	}
+	return archive.ReadAll(resp.Body)
+}
+
+// ghAPI runs gh api, reading its output up to archive.MaxSize
+func ghAPI(ctx context.Context, apiPath string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "gh", append([]string{"api", apiPath}, args...)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	data, err := archive.ReadAll(stdout)
+	if err != nil {
+		// Stops gh instead of waiting for the rest
+		cancel()
+		cmd.Wait()
+		return nil, fmt.Errorf("%s: %w", apiPath, err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func ghInstalled() bool {
+	_, err := exec.LookPath("gh")
+	return err == nil
+}
+
+func ghGetContent(ctx context.Context, src githubSource, file string) (string, error) {
+	var apiPath string
+	if src.Path != "" {
+		apiPath = fmt.Sprintf("/repos/%s/%s/contents/%s/%s", src.User, src.Repo, src.Path, file)
+	} else {
+		apiPath = fmt.Sprintf("/repos/%s/%s/contents/%s", src.User, src.Repo, file)
+	}
+	if src.Ref != "" {
+		apiPath += "?ref=" + src.Ref
+	}
+	output, err := ghAPI(ctx, apiPath, "-H", "Accept: application/vnd.github.raw+json")
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
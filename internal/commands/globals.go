@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/config"
+)
+
+// loadedConfig is the effective config for this run, loaded on first use
+var loadedConfig *config.Config
+
+var (
+	// noCacheFlag bypasses cached registry data (--no-cache)
+	noCacheFlag bool
+	// offlineFlag serves only from the cache (--offline)
+	offlineFlag bool
+)
+
+// ApplyGlobalFlags feeds the root command's persistent flags into the config
+// layers and applies UI settings. It runs before every command.
+func ApplyGlobalFlags(cmd *cobra.Command) error {
+	if f := cmd.Flags().Lookup("registry"); f != nil && f.Changed {
+		config.SetFlag("registry", f.Value.String(), "--registry")
+	}
+	noCacheFlag, _ = cmd.Flags().GetBool("no-cache")
+	offlineFlag, _ = cmd.Flags().GetBool("offline")
+	if noCacheFlag && offlineFlag {
+		return fmt.Errorf("--no-cache and --offline can't be used together")
+	}
+
+	// Flags may have changed the effective config
+	loadedConfig = nil
+	openedCache = nil
+	cfg := loadConfig()
+
+	switch cfg.Get("ui.color") {
+	case "never":
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case "always":
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	}
+
+	return nil
+}
+
+// loadConfig returns the effective config. A broken config file is reported
+// once and the defaults are used instead of failing every command.
+func loadConfig() *config.Config {
+	if loadedConfig != nil {
+		return loadedConfig
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		if cfg == nil {
+			cfg = config.Default()
+		}
+	}
+	for _, w := range cfg.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	loadedConfig = cfg
+	return cfg
+}
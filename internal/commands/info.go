@@ -1,16 +1,21 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/packref"
 )
 
 func InfoCmd() *cobra.Command {
 	var jsonFlag bool
+	var preFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "info <pack>",
@@ -20,6 +25,7 @@ func InfoCmd() *cobra.Command {
 USAGE:
   packs info commit-message           Show pack details
   packs info commit-message@1.0.0     Specific version
+  packs info commit-message@^1.2      Version a range selects
   packs info --json commit-message    Output as JSON
 
 INFORMATION SHOWN:
@@ -35,29 +41,36 @@ EXAMPLES:
   packs info --json react-query       # JSON output for scripts`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runInfo(args[0], jsonFlag)
+			return runInfo(args[0], jsonFlag, preFlag)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output as JSON")
+	cmd.Flags().BoolVar(&preFlag, "pre", false, "Allow prerelease versions")
 
 	return cmd
 }
 
-func runInfo(pack string, jsonOutput bool) error {
-	// Parse version
-	name := pack
-	version := "latest"
-	if idx := strings.Index(pack, "@"); idx != -1 {
-		name = pack[:idx]
-		version = pack[idx+1:]
+func runInfo(pack string, jsonOutput bool, pre bool) error {
+	r, err := packref.Parse(pack)
+	if err != nil {
+		return err
+	}
+	if r.Kind != packref.Registry {
+		return fmt.Errorf("info only looks up registry packs, not %s\nInstall it with: packs get %s", r, r)
+	}
+	name := r.Name
+	version := r.Version
+	if version == "" {
+		version = "latest"
 	}
 
-	// TODO: Fetch from packs.sh API
-	// For now, return demo data
-	info := getPackInfo(name, version)
-	if info == nil {
-		return fmt.Errorf("pack not found: %s", pack)
+	info, err := fetchPackInfo(name, version, pre)
+	if errors.Is(err, api.ErrNotFound) {
+		return fmt.Errorf("pack not found: %s\nSearch for it with: packs find %s", pack, name)
+	}
+	if err != nil {
+		return err
 	}
 
 	if jsonOutput {
@@ -77,11 +90,18 @@ func runInfo(pack string, jsonOutput bool) error {
 
 	fmt.Printf("\n  %s %s\n", typeIcon, info.Name)
 	fmt.Printf("  %s\n\n", strings.Repeat("─", 50))
-	fmt.Printf("  %-14s %s\n", "Version:", info.Version)
+	if info.Constraint != "" && !api.IsLatest(info.Constraint) && info.Constraint != info.Version {
+		fmt.Printf("  %-14s %s (selected by %s)\n", "Version:", info.Version, info.Constraint)
+	} else {
+		fmt.Printf("  %-14s %s\n", "Version:", info.Version)
+	}
 	fmt.Printf("  %-14s %s\n", "Type:", info.Type)
 	fmt.Printf("  %-14s %s\n", "Author:", info.Author)
 	fmt.Printf("  %-14s ★ %d\n", "Stars:", info.Stars)
 	fmt.Printf("  %-14s %s\n", "License:", info.License)
+	if info.Registry != "" {
+		fmt.Printf("  %-14s %s\n", "Registry:", info.Registry)
+	}
 	fmt.Printf("\n  %s\n", info.Description)
 	if len(info.Tags) > 0 {
 		fmt.Printf("\n  Tags: %s\n", strings.Join(info.Tags, ", "))
@@ -97,6 +117,7 @@ func runInfo(pack string, jsonOutput bool) error {
 type PackDetail struct {
 	Name        string   `json:"name"`
 	Version     string   `json:"version"`
+	Constraint  string   `json:"constraint,omitempty"`
 	Type        string   `json:"type"`
 	Description string   `json:"description"`
 	Author      string   `json:"author"`
@@ -106,60 +127,42 @@ type PackDetail struct {
 	Tags        []string `json:"tags"`
 	Versions    []string `json:"versions"`
 	GithubRef   string   `json:"github_ref,omitempty"`
+	Registry    string   `json:"registry,omitempty"`
 	CreatedAt   string   `json:"created_at"`
 	UpdatedAt   string   `json:"updated_at"`
 }
 
-func getPackInfo(name, version string) *PackDetail {
-	// Demo data
-	packs := map[string]*PackDetail{
-		"commit-message": {
-			Name:        "commit-message",
-			Version:     "1.0.0",
-			Type:        "skill",
-			Description: "Generate conventional commit messages from staged changes. Analyzes git diff and produces well-formatted commits following the Conventional Commits specification.",
-			Author:      "tunajam",
-			Stars:       892,
-			Downloads:   4521,
-			License:     "MIT",
-			Tags:        []string{"git", "commits", "conventional-commits"},
-			Versions:    []string{"1.0.0"},
-			CreatedAt:   "2026-01-15",
-			UpdatedAt:   "2026-01-28",
-		},
-		"humanizer": {
-			Name:        "humanizer",
-			Version:     "1.0.0",
-			Type:        "skill",
-			Description: "Remove signs of AI-generated writing from text. Based on Wikipedia's comprehensive guide to AI writing patterns, detecting 24 common issues.",
-			Author:      "blader",
-			Stars:       543,
-			Downloads:   2187,
-			License:     "MIT",
-			Tags:        []string{"writing", "editing", "ai-detection"},
-			Versions:    []string{"1.0.0"},
-			GithubRef:   "blader/humanizer",
-			CreatedAt:   "2026-01-20",
-			UpdatedAt:   "2026-01-29",
-		},
-		"react-query": {
-			Name:        "react-query",
-			Version:     "2.1.0",
-			Type:        "context",
-			Description: "React Query (TanStack Query) patterns, best practices, and common pitfalls. Comprehensive reference for data fetching, caching, and state management.",
-			Author:      "tunajam",
-			Stars:       1247,
-			Downloads:   6892,
-			License:     "MIT",
-			Tags:        []string{"react", "tanstack", "data-fetching", "caching"},
-			Versions:    []string{"2.1.0", "2.0.0", "1.0.0"},
-			CreatedAt:   "2026-01-10",
-			UpdatedAt:   "2026-01-29",
-		},
+// fetchPackInfo loads the version a constraint selects and the available
+// versions from the store
+func fetchPackInfo(name, constraint string, pre bool) (*PackDetail, error) {
+	store := newStore()
+	ctx := context.Background()
+
+	version, err := api.ResolveVersion(ctx, store, name, constraint, pre)
+	if err != nil {
+		return nil, err
 	}
 
-	if p, ok := packs[name]; ok {
-		return p
+	p, err := store.Get(ctx, name, version)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	// Versions are best-effort; not every registry can list them
+	versions, _ := store.ListVersions(ctx, name)
+
+	return &PackDetail{
+		Name:        p.Name,
+		Version:     p.Version,
+		Constraint:  constraint,
+		Type:        p.Type,
+		Description: p.Description,
+		Author:      p.Author,
+		Stars:       int(p.Stars),
+		License:     p.License,
+		Tags:        p.Tags,
+		Versions:    api.SortVersions(versions),
+		GithubRef:   p.GithubRef,
+		Registry:    p.Registry,
+	}, nil
 }
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tunajam/packs/internal/atomicfile"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/managed"
+	packfmt "github.com/tunajam/packs/internal/pack"
+)
+
+// installBlocks stages the files laid out for a pack with its managed
+// blocks written in, leaving everything around the blocks alone, swaps them
+// in as part of tx and records the blocks. Blocks in merged get that body
+// instead of the pack's, or stay out when it's nil.
+func installBlocks(tx *installTx, stage, root string, meta *installed.Meta, files []packfmt.File, prev *installed.Pack, merged map[string][]byte) error {
+	meta.Managed = true
+	version := meta.Version
+	if version == "" {
+		version = shortCommit(meta.Commit)
+	}
+
+	written := map[string]bool{}
+	var bodies []packfmt.File
+	for _, f := range files {
+		if !packfmt.ValidPath(f.Path) {
+			return fmt.Errorf("%w: %q", packfmt.ErrUnsafePath, f.Path)
+		}
+		path := filepath.Join(root, filepath.FromSlash(f.Path))
+		data, mode, err := readHostFile(path)
+		if err != nil {
+			return err
+		}
+
+		b := managed.New(meta.Name, version, f.Content)
+		hash := installed.Hash(b.Body)
+		bodies = append(bodies, packfmt.File{Path: f.Path, Content: b.Body})
+		meta.Outputs = append(meta.Outputs, packfmt.File{Path: f.Path, Mode: 0644, SHA256: hash})
+		written[f.Path] = true
+		body, ok := merged[f.Path]
+		if ok && body == nil {
+			// The block was deleted locally, and it stays that way
+			continue
+		}
+		if ok {
+			b.Body = managed.New(meta.Name, version, body).Body
+		}
+		data, err = managed.Upsert(data, b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		staged := filepath.Join(stage, filepath.FromSlash(f.Path))
+		if err := atomicfile.Write(staged, data, mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+		if err := tx.replace(stage, path, staged); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+
+	// Blocks in other files stay where they are
+	if prev != nil && prev.Managed {
+		for _, out := range prev.Outputs {
+			if !written[out.Path] {
+				meta.Outputs = append(meta.Outputs, out)
+			}
+		}
+	}
+	if err := writeBase(tx, root, meta.Name, bodies); err != nil {
+		return err
+	}
+	return writeMeta(tx, stage, root, meta)
+}
+
+// checkBlocks fails when a file already has the pack's managed block
+func checkBlocks(root, name string, files []packfmt.File) error {
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f.Path))
+		data, _, err := readHostFile(path)
+		if err != nil {
+			return err
+		}
+		b, err := managed.Find(data, name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if b != nil {
+			return fmt.Errorf("%w: %s in %s\nUse --force to overwrite, or --on-conflict=backup to keep a copy", errPackExists, name, path)
+		}
+	}
+	return nil
+}
+
+// removeBlocks takes a pack's managed blocks out of the files holding them,
+// deleting a file only when nothing else is left in it
+func removeBlocks(p *installed.Pack) error {
+	for _, rel := range p.Paths() {
+		path := filepath.Join(p.Root, filepath.FromSlash(rel))
+		data, mode, err := readHostFile(path)
+		if err != nil {
+			return err
+		}
+		data, ok, err := managed.Remove(data, p.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if !ok {
+			continue
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			os.Remove(path)
+			continue
+		}
+		if err := atomicfile.Write(path, data, mode); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// readHostFile reads a file that holds managed blocks, keeping its mode. A
+// missing file is empty.
+func readHostFile(path string) ([]byte, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0644, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.IsDir() {
+		return nil, 0, fmt.Errorf("%s is a directory\nGive a file to inline into, like AGENTS.md", path)
+	}
+	data, err := os.ReadFile(path)
+	return data, info.Mode().Perm(), err
+}
@@ -0,0 +1,427 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/manifest"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/packref"
+)
+
+func InstallCmd() *cobra.Command {
+	var outputFlag string
+	var agentFlag string
+	var scopeFlag string
+	var inlineFlag string
+	var frozenFlag bool
+	var copyFlag bool
+	var forceFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the packs listed in packs.yaml",
+		Long: `Install every pack in the project's packs.yaml, exactly as pinned in
+packs.lock.
+
+Packs already in packs.lock are installed at their locked version, registry,
+commit and content hash, so every machine gets byte-identical files. New or
+changed entries are resolved and written to packs.lock. packs.yaml and
+packs.lock are found from anywhere in the project.
+
+Local edits to installed packs are merged into what's installed, as
+'packs update' does; packs whose edits conflict stop the install. A pack
+in the way that packs didn't install does too. --force overwrites both.
+
+MANIFEST (packs.yaml):
+  packs:
+    commit-message: ^1.2          # any semver range, or "latest"
+    react-patterns: 1.0.0
+    docx: gh:anthropics/skills/docx@main
+    house-style: ./vendor/house-style.tgz
+
+FLAGS:
+  -o, --output <path>   Install to specific directory
+  -a, --agent <names>   Install for these agents (comma-separated)
+      --scope <scope>   project (commit them with the code) or user
+      --inline <file>   Write each pack into a managed block in this file
+      --frozen          Fail instead of updating packs.lock (for CI)
+      --copy            Copy files instead of linking them to the store
+  -f, --force           Overwrite local edits and packs installed elsewhere
+
+EXAMPLES:
+  packs get commit-message --save   # Add a pack to packs.yaml
+  packs install                     # Install everything
+  packs install --frozen            # CI: install exactly packs.lock
+  packs install --scope project     # Into the repo, to commit them
+  packs install --inline AGENTS.md  # As managed blocks in AGENTS.md`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInstall(cmd.Context(), outputFlag, agentFlag, scopeFlag, inlineFlag, frozenFlag, copyFlag, forceFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFlag, "output", "o", "", "Install to specific directory")
+	cmd.Flags().StringVarP(&agentFlag, "agent", "a", "", "Install for these agents (comma-separated)")
+	cmd.Flags().StringVar(&scopeFlag, "scope", "", "Install to the project or user scope")
+	cmd.Flags().StringVar(&inlineFlag, "inline", "", "Write into managed blocks in this file (e.g. AGENTS.md)")
+	cmd.Flags().BoolVar(&frozenFlag, "frozen", false, "Fail if packs.lock is missing or out of date")
+	cmd.Flags().BoolVar(&copyFlag, "copy", false, "Copy files instead of linking them to the store")
+	cmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Overwrite local edits and packs installed elsewhere")
+
+	return cmd
+}
+
+func runInstall(ctx context.Context, outputDir, agent, scope, inline string, frozen, copyFiles, force bool) error {
+	locs, err := resolveLocations(agent, scope, outputDir, inline)
+	if err != nil {
+		return err
+	}
+
+	m, err := manifest.ReadManifest(manifestPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no %s in this project\nAdd packs with: packs get <pack> --save", manifest.ManifestFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	lock, err := manifest.ReadLock(lockPath())
+	if errors.Is(err, os.ErrNotExist) {
+		if frozen {
+			return fmt.Errorf("no %s; run 'packs install' without --frozen to create it", manifest.LockFile)
+		}
+		lock = &manifest.Lock{}
+	} else if err != nil {
+		return err
+	}
+
+	deps := m.Dependencies()
+	changed := len(lock.Packs) != len(deps)
+	resolved := &manifest.Lock{}
+	fetched := map[string]*fetchedPack{}
+
+	// Fetch everything before writing anything, so a failure installs nothing
+	for _, dep := range deps {
+		if err := checkPackName(dep.Name, manifest.ManifestFile); err != nil {
+			return err
+		}
+		locked := lock.Find(dep.Name)
+		if locked != nil && locked.Spec == dep.Spec {
+			f, err := fetchLocked(ctx, *locked)
+			if err != nil {
+				return whenInterrupted(ctx, err)
+			}
+			fetched[dep.Name] = f
+			resolved.Put(*locked)
+			continue
+		}
+
+		if frozen {
+			return fmt.Errorf("%s is out of date: %s is %s in %s\nRun 'packs install' without --frozen and commit %s",
+				manifest.LockFile, dep.Name, dep.Spec, manifest.ManifestFile, manifest.LockFile)
+		}
+
+		f, err := fetchPack(ctx, fromProject(dep.Ref()), false)
+		if err != nil {
+			return whenInterrupted(ctx, err)
+		}
+		f.Locked.Name = dep.Name
+		f.Locked.Spec = dep.Spec
+		lp := f.Locked
+		fetched[dep.Name] = f
+		resolved.Put(lp)
+		changed = true
+	}
+	if frozen && changed {
+		return fmt.Errorf("%s lists packs that are not in %s\nRun 'packs install' without --frozen and commit %s",
+			manifest.LockFile, manifest.ManifestFile, manifest.LockFile)
+	}
+
+	var dirs []string
+	for _, loc := range locs {
+		dirs = append(dirs, loc.Dir)
+	}
+
+	// An interrupted or failed install puts every pack back as it was
+	tx := newInstallTx(ctx)
+	defer tx.Rollback()
+	for _, lp := range resolved.Packs {
+		for _, loc := range locs {
+			opts, err := reinstallOpts(ctx, loc, fetched[lp.Name], force)
+			if errors.Is(err, errLocalEdits) {
+				return fmt.Errorf("%s %w\nSee them with: packs diff %s, or use --force to overwrite them", lp.Name, err, lp.Name)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", lp.Name, err)
+			}
+			opts.copy = copyFiles
+			if _, err := installTarget(tx, loc, fetched[lp.Name], opts); err != nil {
+				return fmt.Errorf("%s: %w", lp.Name, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, lp := range resolved.Packs {
+		fmt.Printf("  ✓ %s  %s\n", lockedLabel(lp), lockedSource(lp))
+	}
+	fmt.Printf("\n✓ Installed %d packs to %s\n", len(resolved.Packs), strings.Join(dirs, ", "))
+
+	if changed {
+		if err := manifest.WriteLock(lockPath(), resolved); err != nil {
+			return fmt.Errorf("failed to write %s: %w", manifest.LockFile, err)
+		}
+		fmt.Printf("✓ Updated %s\n", manifest.LockFile)
+	}
+	return nil
+}
+
+// reinstallOpts is how install replaces what's already in a location. A
+// pack it installed before is replaced with its local edits merged in, or
+// fails with errLocalEdits when they conflict; anything else in the way
+// fails. force replaces either.
+func reinstallOpts(ctx context.Context, loc agents.Location, fetched *fetchedPack, force bool) (installOpts, error) {
+	if force {
+		return installOpts{force: true}, nil
+	}
+	name := fetched.Locked.Name
+	files, _, err := layoutFor(loc, fetched)
+	if err != nil {
+		return installOpts{}, err
+	}
+	p, err := installed.Read(loc.Dir, name)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := checkExisting(loc, name, files); errors.Is(err, errPackExists) {
+			return installOpts{}, fmt.Errorf("%w in %s\nUse --force to overwrite it", errPackExists, conflictWhere(loc, files))
+		} else if err != nil {
+			return installOpts{}, err
+		}
+		return installOpts{}, nil
+	}
+	if err != nil {
+		return installOpts{}, err
+	}
+
+	version := "upstream"
+	if fetched.Locked.Version != "" {
+		version = fetched.Locked.Version
+	} else if fetched.Locked.Commit != "" {
+		version = shortCommit(fetched.Locked.Commit)
+	}
+	merged, err := keepEdits(ctx, loc, p, files, version)
+	return installOpts{force: true, merged: merged}, err
+}
+
+// manifestPath is the project's packs.yaml, found from anywhere in it
+func manifestPath() string {
+	return filepath.Join(agents.ProjectRoot(), manifest.ManifestFile)
+}
+
+// lockPath is the project's packs.lock, next to packs.yaml
+func lockPath() string {
+	return filepath.Join(agents.ProjectRoot(), manifest.LockFile)
+}
+
+// fromProject resolves a path reference from packs.yaml or packs.lock,
+// which is relative to the project root, from the current directory
+func fromProject(r packref.PackRef) packref.PackRef {
+	root := agents.ProjectRoot()
+	if root == "." || !isPathRef(r) || filepath.IsAbs(r.Path) {
+		return r
+	}
+	r.Path = filepath.ToSlash(filepath.Join(root, r.Path))
+	return r
+}
+
+// toProject rewrites a path reference given from the current directory
+// to be relative to the project root, as packs.yaml and packs.lock record
+// it. Absolute paths outside the project stay absolute; other references
+// are returned as they are.
+func toProject(ref string) string {
+	r, err := packref.Parse(ref)
+	if err != nil || !isPathRef(r) {
+		return ref
+	}
+	root, err := filepath.Abs(agents.ProjectRoot())
+	if err != nil {
+		return ref
+	}
+	abs, err := filepath.Abs(filepath.FromSlash(r.Path))
+	if err != nil {
+		return ref
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || filepath.IsAbs(r.Path) && (rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+		return ref
+	}
+	r.Path = filepath.ToSlash(rel)
+	return r.String()
+}
+
+// installedRef is the source an install records for a pack from a path
+// reference in project form: as it is for project installs, like
+// packs.lock, and absolute for the others, so outdated and update find
+// the pack from any directory
+func installedRef(ref string, scope agents.Scope) string {
+	r, err := packref.Parse(ref)
+	if err != nil || !isPathRef(r) || scope == agents.ScopeProject {
+		return ref
+	}
+	abs, err := filepath.Abs(filepath.FromSlash(fromProject(r).Path))
+	if err != nil {
+		return ref
+	}
+	r.Path = filepath.ToSlash(abs)
+	return r.String()
+}
+
+// isPathRef reports whether a reference is a directory or archive on disk
+func isPathRef(r packref.PackRef) bool {
+	return (r.Kind == packref.Local || r.Kind == packref.Archive) && !r.IsURL()
+}
+
+// fetchLocked fetches exactly what a lock entry pins and checks its digest
+func fetchLocked(ctx context.Context, lp manifest.LockedPack) (*fetchedPack, error) {
+	if err := checkPackName(lp.Name, manifest.LockFile); err != nil {
+		return nil, err
+	}
+	var files []packfmt.File
+	var packType string
+
+	switch lp.Source {
+	case "archive", "local", "git":
+		r, err := lockedRef(lp)
+		if err != nil {
+			return nil, err
+		}
+		fetched, err := fetchPack(ctx, r, false)
+		if err != nil {
+			return nil, err
+		}
+		files, packType = fetched.Files, fetched.Type
+	case "github":
+		if offlineFlag {
+			return nil, fmt.Errorf("GitHub packs are not available offline: %s", lp.Ref)
+		}
+		r, err := lockedRef(lp)
+		if err != nil {
+			return nil, err
+		}
+		src := githubSourceOf(r)
+		var signature []byte
+		if files, signature, err = getFromGitHub(ctx, src); err != nil {
+			return nil, err
+		}
+		if err := checkSignature(githubSubject(src, files, signature)); err != nil {
+			return nil, err
+		}
+		packType = filesPackType(files)
+	default:
+		registries := newRegistries("")
+		var store api.PackStore = registries
+		if s := registries.Store(lp.Registry); s != nil {
+			store = s
+		}
+		p, err := store.Get(ctx, lp.Name, lp.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", lockedLabel(lp), err)
+		}
+		if err := checkSignature(registrySubject(p)); err != nil {
+			return nil, err
+		}
+		files, packType = p.FileSet(), p.Type
+	}
+
+	if got := packfmt.Digest(files); lp.ContentHash != "" && got != lp.ContentHash {
+		return nil, fmt.Errorf("content of %s doesn't match %s\n  locked: %s\n  got:    %s",
+			lockedLabel(lp), manifest.LockFile, lp.ContentHash, got)
+	}
+	return &fetchedPack{Files: files, Type: packType, Locked: lp}, nil
+}
+
+// lockedRef is the reference a lock entry pins: its source, at its commit
+// when it has one
+func lockedRef(lp manifest.LockedPack) (packref.PackRef, error) {
+	r, err := packref.Parse(lp.Ref)
+	if err != nil {
+		return r, fmt.Errorf("%s in %s: %w", lp.Name, manifest.LockFile, err)
+	}
+	if lp.Commit != "" {
+		if !commitHash.MatchString(lp.Commit) {
+			return r, fmt.Errorf("%s in %s: invalid commit %q", lp.Name, manifest.LockFile, lp.Commit)
+		}
+		r.Rev = lp.Commit
+	}
+	return fromProject(r), nil
+}
+
+// savePack records an installed pack in packs.yaml and packs.lock
+func savePack(lp manifest.LockedPack, spec string) error {
+	if err := manifest.AddDependency(manifestPath(), lp.Name, spec); err != nil {
+		return fmt.Errorf("failed to update %s: %w", manifest.ManifestFile, err)
+	}
+
+	lock, err := manifest.ReadLock(lockPath())
+	if errors.Is(err, os.ErrNotExist) {
+		lock = &manifest.Lock{}
+	} else if err != nil {
+		return err
+	}
+	lp.Spec = spec
+	lock.Put(lp)
+	if err := manifest.WriteLock(lockPath(), lock); err != nil {
+		return fmt.Errorf("failed to write %s: %w", manifest.LockFile, err)
+	}
+
+	fmt.Printf("✓ Saved %s: %s to %s\n", lp.Name, spec, manifest.ManifestFile)
+	return nil
+}
+
+// saveSpec is the manifest entry for a get reference: the reference for
+// packs from outside a registry, the requested constraint, or a caret range
+// on the installed version
+func saveSpec(r packref.PackRef, lp manifest.LockedPack) string {
+	switch {
+	case isPathRef(r):
+		// Already relative to the project root
+		return lp.Ref
+	case r.Kind != packref.Registry:
+		return r.String()
+	}
+	if r.Version != "" {
+		return r.Version
+	}
+	if api.IsExactVersion(lp.Version) {
+		return "^" + lp.Version
+	}
+	return "latest"
+}
+
+func lockedLabel(lp manifest.LockedPack) string {
+	switch {
+	case lp.Version != "":
+		return lp.Name + "@" + lp.Version
+	case lp.Commit != "":
+		return lp.Name + "@" + lp.Commit[:7]
+	default:
+		return lp.Name
+	}
+}
+
+func lockedSource(lp manifest.LockedPack) string {
+	switch lp.Source {
+	case "github", "git", "local", "archive":
+		return "[" + lp.Ref + "]"
+	}
+	return "[" + lp.Registry + "]"
+}
@@ -0,0 +1,241 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/manifest"
+)
+
+func ListCmd() *cobra.Command {
+	var agentFlag string
+	var scopeFlag string
+	var typeFlag string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List installed packs",
+		Long: `List the packs installed in every known agent directory, project scope
+first, then user scope.
+
+Only packs that packs installed are listed: each directory keeps an index,
+.packs/<name>.json, with every pack's source, version and the files it
+wrote. Packs whose files changed since install are marked ✎ modified.
+
+DIRECTORIES:
+             PROJECT                  USER
+  custom     skills_dir from config, in the scope of the file that sets it
+  claude     .claude/skills/          ~/.claude/skills/
+  clawdbot   skills/                  ~/.clawdbot/skills/
+  codex      .codex/skills/           ~/.codex/skills/
+  cursor     .cursor/rules/
+  copilot    .github/instructions/
+  gemini     .gemini/extensions/      ~/.gemini/extensions/
+  aider      conventions/
+  generic    .packs/skills/           ~/.packs/skills/
+
+  Project directories are relative to the project root: the nearest
+  directory up with a packs.yaml, .packs/config.yaml or .git.
+
+  Managed blocks (packs get --inline) in the project root's Markdown files
+  and in ~/.claude, ~/.codex and ~/.gemini are listed when their .packs/
+  entry is there, including ones someone else committed.
+
+FLAGS:
+  -a, --agent <name>   Only packs for one agent
+      --scope <scope>  Only project or user packs
+  -t, --type <type>    Only skill, context or prompt packs
+  -j, --json           Output as JSON
+
+EXAMPLES:
+  packs list                  # Everything installed
+  packs ls --agent claude     # Claude Code skills only
+  packs ls --scope project    # Packs committed with this project
+  packs list --json | jq '.[] | select(.modified)'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(agentFlag, scopeFlag, typeFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentFlag, "agent", "a", "", "Filter by agent")
+	cmd.Flags().StringVar(&scopeFlag, "scope", "", "Filter by scope (project, user)")
+	cmd.Flags().StringVarP(&typeFlag, "type", "t", "", "Filter by type (skill, context, prompt)")
+	cmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output as JSON")
+
+	return cmd
+}
+
+// installedPacks lists packs in every known agent directory. A directory
+// known under several agents is only scanned once.
+func installedPacks(agent, scope, packType string) ([]*installed.Pack, error) {
+	if _, err := agents.ParseScope(scope); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var all []*installed.Pack
+	for _, loc := range knownLocations() {
+		if agent != "" && loc.Target.Name() != agent {
+			continue
+		}
+		if scope != "" && string(loc.Scope) != scope {
+			continue
+		}
+		abs, _ := filepath.Abs(loc.Dir)
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+
+		packs, err := installed.List(loc.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", loc.Dir, err)
+		}
+		for _, p := range packs {
+			if packType != "" && p.Type != packType {
+				continue
+			}
+			// Packs installed before agents and scopes were recorded
+			if p.Agent == "" {
+				p.Agent = loc.Target.Name()
+			}
+			if p.Scope == "" {
+				p.Scope = string(loc.Scope)
+			}
+			all = append(all, p)
+		}
+	}
+	return all, nil
+}
+
+func runList(agent, scope, packType string, jsonOutput bool) error {
+	packs, err := installedPacks(agent, scope, packType)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		if packs == nil {
+			packs = []*installed.Pack{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(packs)
+	}
+
+	if len(packs) == 0 {
+		fmt.Println("No packs installed. Run 'packs get <pack>' to install one.")
+		return nil
+	}
+
+	fmt.Printf("\n  %d packs installed\n", len(packs))
+	modified := 0
+	for _, scope := range []string{string(agents.ScopeProject), string(agents.ScopeUser)} {
+		var inScope []*installed.Pack
+		for _, p := range packs {
+			if p.Scope == scope {
+				inScope = append(inScope, p)
+			}
+		}
+		if len(inScope) == 0 {
+			continue
+		}
+
+		if scope == string(agents.ScopeProject) {
+			root, _ := filepath.Abs(agents.ProjectRoot())
+			fmt.Printf("\n  Project (%s):\n\n", root)
+		} else {
+			fmt.Printf("\n  User:\n\n")
+		}
+		for _, p := range inScope {
+			if printInstalled(p) {
+				modified++
+			}
+			if shared := sharedWith(p); len(shared) > 0 {
+				fmt.Printf("     ↳ shares files with %s\n", strings.Join(shared, ", "))
+			}
+			if note := overlayNote(p); note != "" {
+				fmt.Printf("     ↳ %s\n", note)
+			}
+		}
+	}
+	if modified > 0 {
+		fmt.Printf("\n  %d packs were edited after install\n", modified)
+	}
+	fmt.Println()
+	return nil
+}
+
+// printInstalled prints one list row and reports whether the pack was
+// edited since install
+func printInstalled(p *installed.Pack) bool {
+	typeIcon := "📦"
+	switch p.Type {
+	case "context":
+		typeIcon = "📚"
+	case "prompt":
+		typeIcon = "💬"
+	}
+	lp := manifest.LockedPack{Name: p.Name, Version: p.Version, Commit: p.Commit, Source: p.Source, Ref: p.Ref, Registry: p.Registry}
+	source := "-"
+	if p.Source != "" {
+		source = lockedSource(lp)
+	}
+	age := "-"
+	if !p.InstalledAt.IsZero() {
+		age = formatAge(time.Since(p.InstalledAt))
+	}
+	mark := ""
+	if p.Managed {
+		mark = "  in " + strings.Join(p.Paths(), ", ")
+	}
+	if p.Modified {
+		mark += "  ✎ modified"
+	}
+	fmt.Printf("  %s %-30s %-9s %-24s %6s%s\n",
+		typeIcon, truncate(lockedLabel(lp), 30), p.Agent, truncate(source, 24), age, mark)
+	return p.Modified
+}
+
+// sharedWith lists the other installs linked to the same store entry as a
+// pack, by agent and scope where the directory is a known one
+func sharedWith(p *installed.Pack) []string {
+	if p.Store == "" {
+		return nil
+	}
+	refs, err := newPackStore().Refs(p.Store)
+	if err != nil {
+		return nil
+	}
+
+	self := storeRef(p.Root, p.Name)
+	locs := knownLocations()
+	var shared []string
+	for _, ref := range refs {
+		if ref == self {
+			continue
+		}
+		label := ref.Root
+		for _, loc := range locs {
+			if abs, _ := filepath.Abs(loc.Dir); abs == ref.Root {
+				label = fmt.Sprintf("%s (%s)", loc.Target.Name(), loc.Scope)
+				break
+			}
+		}
+		if ref.Name != p.Name {
+			label = ref.Name + " in " + label
+		}
+		shared = append(shared, label)
+	}
+	return shared
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/packref"
+	"github.com/tunajam/packs/internal/signing"
+)
+
+// fetchFromDir installs a pack from a directory on disk, like one being
+// written. The name, version and type come from its pack.yaml, or the name
+// from the directory.
+func fetchFromDir(r packref.PackRef) (*fetchedPack, error) {
+	dir, err := filepath.Abs(filepath.FromSlash(r.Path))
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("no pack directory at %s", r)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory\nPoint at the pack directory, or at a .tar.gz, .tgz or .zip archive", r)
+	}
+	return readPackDir(r, dir, filepath.Base(dir))
+}
+
+// readPackDir reads a pack's files and pack.sig from a directory. Packs
+// without a name in their pack.yaml are called name.
+func readPackDir(r packref.PackRef, dir, name string) (*fetchedPack, error) {
+	files, err := packfmt.ReadFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r, err)
+	}
+	if len(files) > maxPackFiles {
+		return nil, fmt.Errorf("%s has %d files, more than the %d a pack can have\nPoint at the pack directory", r, len(files), maxPackFiles)
+	}
+	signature, err := os.ReadFile(filepath.Join(dir, signing.SigFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return fetchedFromFiles(r, name, files, signature)
+}
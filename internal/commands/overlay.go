@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/overlay"
+	packfmt "github.com/tunajam/packs/internal/pack"
+)
+
+// layoutFor lays a pack out for a location. Project installs get the
+// project's overlays for the pack applied first; the overlay files applied
+// are returned with their hashes.
+func layoutFor(loc agents.Location, fetched *fetchedPack) ([]packfmt.File, []packfmt.File, error) {
+	p := fetched.agentPack()
+	var applied []packfmt.File
+	if loc.Scope == agents.ScopeProject {
+		name := sourceName(fetched)
+		overlays, err := overlay.Load(agents.ProjectRoot(), name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(overlays) > 0 {
+			if p.Files, err = overlay.Apply(p.Files, overlays); err != nil {
+				return nil, nil, fmt.Errorf("overlay for %s doesn't apply: %w\nFix it in %s", name, err, overlay.Path(agents.ProjectRoot(), name))
+			}
+			applied = overlayFiles(overlays)
+		}
+	}
+
+	files, err := loc.Target.Layout(p)
+	return files, applied, err
+}
+
+// sourceName is the name a pack has upstream, which its overlay is kept
+// under even when it's installed under another
+func sourceName(fetched *fetchedPack) string {
+	if fetched.Pack != "" {
+		return fetched.Pack
+	}
+	return fetched.Locked.Name
+}
+
+func overlayFiles(overlays []overlay.Overlay) []packfmt.File {
+	var files []packfmt.File
+	for _, o := range overlays {
+		files = append(files, packfmt.File{Path: o.Path, SHA256: installed.Hash(o.Content)})
+	}
+	return files
+}
+
+// overlayNote describes the overlays of an installed pack for list: the
+// ones applied, and whether the project's have changed since
+func overlayNote(p *installed.Pack) string {
+	var current []packfmt.File
+	if p.Scope == string(agents.ScopeProject) {
+		name := p.Name
+		if p.Pack != "" {
+			name = p.Pack
+		}
+		overlays, err := overlay.Load(agents.ProjectRoot(), name)
+		if err != nil {
+			return "overlay doesn't load: " + strings.SplitN(err.Error(), "\n", 2)[0]
+		}
+		current = overlayFiles(overlays)
+	}
+	if len(current) == 0 && len(p.Overlays) == 0 {
+		return ""
+	}
+
+	shown := p.Overlays
+	if len(shown) == 0 {
+		shown = current
+	}
+	var names []string
+	for _, f := range shown {
+		names = append(names, f.Path)
+	}
+	note := "overlays: " + strings.Join(names, ", ")
+	if !sameOverlays(current, p.Overlays) {
+		note += " (changed since install; reinstall to apply)"
+	}
+	return note
+}
+
+func sameOverlays(a, b []packfmt.File) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	hashes := map[string]string{}
+	for _, f := range a {
+		hashes[f.Path] = f.SHA256
+	}
+	for _, f := range b {
+		if h, ok := hashes[f.Path]; !ok || h != f.SHA256 {
+			return false
+		}
+	}
+	return true
+}
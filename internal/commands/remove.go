@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/installed"
+	"github.com/tunajam/packs/internal/manifest"
+)
+
+func RemoveCmd() *cobra.Command {
+	var agentFlag string
+	var scopeFlag string
+	var dryRunFlag bool
+	var forceFlag bool
+
+	cmd := &cobra.Command{
+		Use:     "remove <pack...>",
+		Aliases: []string{"rm", "uninstall"},
+		Short:   "Uninstall packs",
+		Long: `Remove packs from every agent directory they were installed to, and from
+the project's packs.yaml and packs.lock when present.
+
+Only the files packs wrote are deleted. Files you added to a pack directory
+are kept and listed; --force deletes the whole directory. Packs you edited
+since install are only removed once you confirm, or with --force.
+
+FLAGS:
+  -a, --agent <name>   Only remove from one agent (packs.yaml is left alone)
+      --scope <scope>  Only remove from project or user directories
+                       (packs.yaml is only touched for project)
+  -n, --dry-run        Show what would be deleted
+  -f, --force          Also delete local edits and files packs didn't create
+
+EXAMPLES:
+  packs remove convex                # Everywhere, plus packs.yaml
+  packs rm convex --agent codex      # One agent only
+  packs rm convex --scope user       # Keep the project's copy
+  packs remove convex --dry-run      # Preview`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRemove(cmd.Context(), args, agentFlag, scopeFlag, dryRunFlag, forceFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentFlag, "agent", "a", "", "Only remove from this agent")
+	cmd.Flags().StringVar(&scopeFlag, "scope", "", "Only remove from this scope (project, user)")
+	cmd.Flags().BoolVarP(&dryRunFlag, "dry-run", "n", false, "Show what would be deleted")
+	cmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Delete local edits and files packs didn't create")
+
+	return cmd
+}
+
+func runRemove(ctx context.Context, names []string, agent, scope string, dryRun, force bool) error {
+	packs, err := installedPacks(agent, scope, "")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		var copies []*installed.Pack
+		for _, p := range packs {
+			if p.Name == name {
+				copies = append(copies, p)
+			}
+		}
+		if !force && !dryRun {
+			if err := confirmEdits(ctx, name, copies); err != nil {
+				return err
+			}
+		}
+
+		found := len(copies) > 0
+		for _, p := range copies {
+			if err := removeInstalled(p, dryRun, force); err != nil {
+				return err
+			}
+		}
+
+		// The project manifest describes every agent in the project, so
+		// --agent and --scope user leave it
+		if agent == "" && scope != string(agents.ScopeUser) {
+			listed, err := removeFromProject(name, dryRun)
+			if err != nil {
+				return err
+			}
+			found = found || listed
+		}
+
+		if !found {
+			return fmt.Errorf("pack not installed: %s\nSee installed packs with: packs list", name)
+		}
+	}
+	return nil
+}
+
+// confirmEdits asks before removing copies of a pack with local edits,
+// and fails when there's no one to ask or the answer is no
+func confirmEdits(ctx context.Context, name string, copies []*installed.Pack) error {
+	var edited []string
+	for _, p := range copies {
+		if p.Modified {
+			edited = append(edited, p.Dir)
+		}
+	}
+	if len(edited) == 0 {
+		return nil
+	}
+
+	fmt.Printf("! %s has local edits in %s\n", name, strings.Join(edited, ", "))
+	if !isInteractive() {
+		return fmt.Errorf("%s has local edits\nSee them with: packs diff %s, or use --force to delete them", name, name)
+	}
+	fmt.Printf("  Delete them? [y/N] ")
+	answer, err := readAnswer(ctx)
+	if errors.Is(err, io.EOF) {
+		// Ctrl-D
+		fmt.Println()
+	} else if err != nil {
+		return err
+	}
+	if a := strings.ToLower(answer); a != "y" && a != "yes" {
+		return fmt.Errorf("kept %s and its edits", name)
+	}
+	return nil
+}
+
+// removeInstalled deletes one installed copy of a pack. Files packs didn't
+// write stay unless force is set.
+func removeInstalled(p *installed.Pack, dryRun, force bool) error {
+	foreign, err := installed.Foreign(p)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		edits := ""
+		if p.Modified {
+			edits = ", with local edits"
+		}
+		fmt.Printf("  Would remove %s (%s%s) from %s\n", p.Name, p.Agent, edits, p.Dir)
+		for _, f := range p.Paths() {
+			if p.Managed {
+				f += " (managed block)"
+			}
+			fmt.Printf("      - %s\n", f)
+		}
+		warnForeign(p, foreign, force)
+		return nil
+	}
+
+	if p.Managed {
+		if err := removeBlocks(p); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range p.Paths() {
+			removeFile(p.Root, f)
+		}
+	}
+	if force {
+		for _, dir := range p.Dirs() {
+			if err := os.RemoveAll(filepath.Join(p.Root, dir)); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", dir, err)
+			}
+		}
+	}
+	if err := installed.Delete(p.Root, p.Name); err != nil {
+		return err
+	}
+	if p.Store != "" {
+		newPackStore().RemoveRef(p.Store, storeRef(p.Root, p.Name))
+	}
+	fmt.Printf("✓ Removed %s from %s\n", p.Name, p.Dir)
+	warnForeign(p, foreign, force)
+	return nil
+}
+
+func warnForeign(p *installed.Pack, foreign []string, force bool) {
+	if len(foreign) == 0 {
+		return
+	}
+	if force {
+		fmt.Printf("  ! Also deleting %d files packs didn't create: %s\n", len(foreign), strings.Join(foreign, ", "))
+		return
+	}
+	fmt.Printf("  ! Kept %d files packs didn't create in %s: %s\n", len(foreign), p.Root, strings.Join(foreign, ", "))
+	fmt.Printf("    Use --force to delete them too\n")
+}
+
+// removeFromProject drops a pack from the project's packs.yaml and
+// packs.lock and reports whether either listed it
+func removeFromProject(name string, dryRun bool) (bool, error) {
+	m, err := manifest.ReadManifest(manifestPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	lock, err := manifest.ReadLock(lockPath())
+	if errors.Is(err, os.ErrNotExist) {
+		lock = &manifest.Lock{}
+	} else if err != nil {
+		return false, err
+	}
+
+	_, inManifest := m.Packs[name]
+	inLock := lock.Find(name) != nil
+	if !inManifest && !inLock {
+		return false, nil
+	}
+	if dryRun {
+		fmt.Printf("  Would remove %s from %s and %s\n", name, manifest.ManifestFile, manifest.LockFile)
+		return true, nil
+	}
+
+	if inManifest {
+		if _, err := manifest.RemoveDependency(manifestPath(), name); err != nil {
+			return false, fmt.Errorf("failed to update %s: %w", manifest.ManifestFile, err)
+		}
+		fmt.Printf("✓ Removed %s from %s\n", name, manifest.ManifestFile)
+	}
+	if lock.Remove(name) {
+		if err := manifest.WriteLock(lockPath(), lock); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", manifest.LockFile, err)
+		}
+		fmt.Printf("✓ Removed %s from %s\n", name, manifest.LockFile)
+	}
+	return true, nil
+}
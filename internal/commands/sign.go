@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/config"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/signing"
+)
+
+func SignCmd() *cobra.Command {
+	var keyFlag string
+	var generateFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "sign [dir]",
+		Short: "Sign a pack for publishing",
+		Long: `Sign a pack directory with your ed25519 key.
+
+The signature covers the name and version from pack.yaml plus the digest of
+every file in the pack, pack.yaml included, and is written to pack.sig.
+Commit pack.sig next to pack.yaml; registries serve it with the pack's files
+and 'packs get' verifies it.
+
+KEYS:
+  packs sign --generate-key     Create ~/.packs/keys/signing.key (+ .pub)
+
+  Share the .pub file. Users trust it with:
+    packs config trust ed25519:Rk9P... --author <you>
+
+EXAMPLES:
+  packs sign                    # Sign the pack in the current directory
+  packs sign registry/convex    # Sign another pack directory
+  packs sign --key ci.key .     # Use a different key`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if generateFlag {
+				return generateSigningKey(keyFlag)
+			}
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return runSign(dir, keyFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&keyFlag, "key", "k", defaultSigningKey(), "Private key file")
+	cmd.Flags().BoolVar(&generateFlag, "generate-key", false, "Generate a new signing key")
+
+	return cmd
+}
+
+func defaultSigningKey() string {
+	return filepath.Join(config.Dir(), "keys", "signing.key")
+}
+
+func generateSigningKey(path string) error {
+	pub, err := signing.GenerateKey(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Generated signing key %s\n", signing.KeyID(pub))
+	fmt.Printf("  Private key: %s (keep it secret)\n", path)
+	fmt.Printf("  Public key:  %s.pub\n\n", path)
+	fmt.Printf("  %s\n\n", signing.EncodePublicKey(pub))
+	fmt.Printf("  Others can trust it with: packs config trust %s\n", signing.EncodePublicKey(pub))
+	return nil
+}
+
+func runSign(dir, keyPath string) error {
+	m, err := packfmt.LoadMeta(dir)
+	if err != nil {
+		return fmt.Errorf("no %s in %s: %w", packfmt.MetaFile, dir, err)
+	}
+	if m.Name == "" {
+		abs, _ := filepath.Abs(dir)
+		m.Name = filepath.Base(abs)
+	}
+
+	files, err := packfmt.ReadFiles(dir)
+	if err != nil {
+		return err
+	}
+	if packfmt.MainFile(files, m.Type) == nil {
+		return fmt.Errorf("no content file in %s\nExpected one of: %s", dir, strings.Join(packfmt.ContentFiles, ", "))
+	}
+
+	priv, err := signing.LoadPrivateKey(keyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no signing key at %s\nCreate one with: packs sign --generate-key", keyPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	payload := signing.Payload(m.Name, m.Version, packfmt.Digest(files))
+	sig := &signing.Signature{
+		KeyID:     signing.KeyID(priv.Public().(ed25519.PublicKey)),
+		Signature: signing.Sign(priv, payload),
+	}
+
+	path := filepath.Join(dir, signing.SigFile)
+	if err := os.WriteFile(path, sig.Marshal(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	fmt.Printf("✓ Signed %s@%s (%d files) with key %s\n", m.Name, m.Version, len(files), sig.KeyID)
+	fmt.Printf("  Wrote %s; commit it next to %s\n", path, packfmt.MetaFile)
+	return nil
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tunajam/packs/internal/api"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/signing"
+)
+
+// newVerifier returns the signature verifier for the configured policy and
+// trusted keys
+func newVerifier() *signing.Verifier {
+	cfg := loadConfig()
+	return &signing.Verifier{
+		Policy:        signing.Policy(cfg.Get("signing.policy")),
+		Keys:          cfg.TrustedKeys,
+		AllowUnsigned: cfg.AllowUnsigned,
+	}
+}
+
+// checkSignature applies the signing policy to a fetched pack. Notes go to
+// stderr so piped content stays clean.
+func checkSignature(s signing.Subject) error {
+	v := newVerifier()
+	res, err := v.Check(s)
+	switch {
+	case errors.Is(err, signing.ErrBadSignature):
+		return fmt.Errorf("refusing to install: %w\n\nThe pack changed after it was signed. Nothing was written.", err)
+	case errors.Is(err, signing.ErrIncomplete):
+		return fmt.Errorf("refusing to install: %w\n\nThe signature covers every file in the pack, pack.yaml included, and the registry\nserved only the main content. Nothing was written.", err)
+	case errors.Is(err, signing.ErrUntrusted):
+		return fmt.Errorf("refusing to install: %w\n\nsigning.policy is \"require\". Trust the publisher with:\n  packs config trust <public-key>\nor allow the source in signing.allow_unsigned", err)
+	case err != nil:
+		return err
+	}
+
+	switch {
+	case res.Trusted:
+		fmt.Fprintf(os.Stderr, "✓ Signature verified (key %s)\n", res.KeyID)
+	case res.Signed && v.Policy != signing.PolicyOff:
+		fmt.Fprintf(os.Stderr, "! %s is signed by key %s, which you don't trust; signature not checked\n", s.Name, res.KeyID)
+	}
+	return nil
+}
+
+// registrySubject describes a pack served by a registry. A registry that
+// serves only the main content can't serve a verifiable signed pack.
+func registrySubject(p *api.Pack) signing.Subject {
+	return signing.Subject{
+		Name:        p.Name,
+		Version:     p.Version,
+		ContentHash: packfmt.Digest(p.FileSet()),
+		Author:      p.Author,
+		Registry:    p.Registry,
+		Source:      p.Registry,
+		Signature:   p.Signature,
+		KeyID:       p.KeyID,
+		Incomplete:  len(p.Files) == 0,
+	}
+}
+
+// githubSubject describes a pack fetched from GitHub, from its pack.yaml
+// and the pack.sig fetched with its files
+func githubSubject(src githubSource, files []packfmt.File, signature []byte) signing.Subject {
+	s := signing.Subject{
+		Name:        src.Name(),
+		ContentHash: packfmt.Digest(files),
+		Source:      "gh:" + src.String(),
+	}
+
+	for _, f := range files {
+		if f.Path != packfmt.MetaFile {
+			continue
+		}
+		if m, err := packfmt.ParseMeta(f.Content); err == nil {
+			if m.Name != "" {
+				s.Name = m.Name
+			}
+			s.Version = m.Version
+			s.Author = m.Author
+		}
+	}
+	if sig, err := signing.ParseSignature(signature); err == nil {
+		s.Signature = sig.Signature
+		s.KeyID = sig.KeyID
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/cache"
+	"github.com/tunajam/packs/internal/config"
+	packstore "github.com/tunajam/packs/internal/store"
+)
+
+// newStore returns the PackStore that commands and the TUI read from
+func newStore() api.PackStore {
+	return newRegistries("")
+}
+
+// newAuthStore returns a PackStore that sends the given auth token to the
+// default registry
+func newAuthStore(authToken string) api.PackStore {
+	return newRegistries(authToken)
+}
+
+// newRegistries builds the configured registries in resolution order.
+// The "registry" config key (packs.sh unless overridden) is implicit at
+// config.DefaultPriority unless the registries list includes it; it may be a
+// URL or a local registry directory.
+func newRegistries(authToken string) *api.MultiStore {
+	return buildRegistries(authToken, cacheMode())
+}
+
+// buildRegistries is newRegistries with an explicit cache mode
+func buildRegistries(authToken string, mode api.CacheMode) *api.MultiStore {
+	cfg := loadConfig()
+	defaultURL := cfg.Get("registry")
+	if !cfg.AuthAllowed() {
+		authToken = ""
+	}
+
+	var registries []api.Registry
+	hasDefault := false
+	for _, r := range cfg.Registries {
+		name := r.Name
+		if name == "" {
+			name = api.RegistryName(r.URL)
+		}
+		token := r.Token()
+		if r.URL == defaultURL {
+			hasDefault = true
+			if token == "" {
+				token = authToken
+			}
+		}
+		registries = append(registries, api.Registry{
+			Name:     name,
+			Priority: r.Priority,
+			Store:    withCache(api.Open(r.URL, token), name, mode),
+		})
+	}
+
+	if !hasDefault {
+		name := api.RegistryName(defaultURL)
+		registries = append(registries, api.Registry{
+			Name:     name,
+			Priority: config.DefaultPriority,
+			Store:    withCache(api.Open(defaultURL, authToken), name, mode),
+		})
+	}
+
+	return api.NewMultiStore(registries...)
+}
+
+// openedCache is the local cache for this run, opened on first use
+var openedCache *cache.Cache
+
+// newCache returns the local cache with the configured dir, TTL and size.
+// Every registry shares it, so it keeps one count of its size.
+func newCache() *cache.Cache {
+	if openedCache == nil {
+		cfg := loadConfig()
+		openedCache = cache.New(cfg.Path("cache.dir"), cfg.Duration("cache.ttl"), cfg.Size("cache.max_size"))
+	}
+	return openedCache
+}
+
+// newPackStore opens the store installed packs link to
+func newPackStore() *packstore.Store {
+	return packstore.New(loadConfig().Path("store.dir"))
+}
+
+// cacheMode returns the cache mode selected by --offline and --no-cache
+func cacheMode() api.CacheMode {
+	switch {
+	case offlineFlag:
+		return api.CacheOffline
+	case noCacheFlag:
+		return api.CacheBypass
+	default:
+		return api.CacheNormal
+	}
+}
+
+// withCache puts the local cache in front of a remote store. Local
+// registries are already on disk and are used directly.
+func withCache(store api.PackStore, registry string, mode api.CacheMode) api.PackStore {
+	if _, ok := store.(*api.LocalStore); ok {
+		return store
+	}
+	return api.NewCachedStore(store, newCache(), registry, mode)
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/packref"
 )
 
 func SubmitCmd() *cobra.Command {
@@ -62,26 +62,20 @@ func runSubmit(ref string) error {
 		return fmt.Errorf("authentication required\n\nRun 'packs login' to authenticate with GitHub")
 	}
 
-	// Normalize @ to gh:
-	if strings.HasPrefix(ref, "@") {
-		ref = ref[1:] // Strip @
-	} else if strings.HasPrefix(ref, "gh:") {
-		ref = ref[3:] // Strip gh:
-	}
-
 	// Validate format
-	parts := strings.SplitN(ref, "/", 3)
-	if len(parts) < 3 {
+	r, err := packref.Parse(ref)
+	if err != nil || r.Kind != packref.GitHub || r.Path == "" {
 		return fmt.Errorf("invalid reference: %s\nExpected format: @user/repo/path or gh:user/repo/path", ref)
 	}
+	ref = strings.TrimPrefix(r.String(), "gh:")
 
 	fmt.Printf("\n  📦 Submitting %s...\n\n", ref)
 
 	// Submit to API with auth
-	client := api.NewWithAuth(authToken)
+	store := newAuthStore(authToken)
 	ctx := context.Background()
 	
-	name, version, message, err := client.Submit(ctx, ref)
+	name, version, message, err := store.Submit(ctx, ref)
 	if err != nil {
 		return fmt.Errorf("failed to submit: %w", err)
 	}
@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/tunajam/packs/internal/atomicfile"
+	"github.com/tunajam/packs/internal/installed"
+)
+
+// errInterrupted is returned when Ctrl-C or SIGTERM stops an install
+var errInterrupted = errors.New("interrupted")
+
+// installTx installs packs all or nothing. Each pack is written to a
+// staging directory inside its agent directory first, then swapped into
+// place one top-level entry at a time. What a swap replaces is kept in the
+// staging directory until Commit, so Rollback can put every entry back as
+// it was.
+type installTx struct {
+	ctx      context.Context
+	swaps    []swap
+	stages   []string
+	onCommit []func()
+	done     bool
+}
+
+// swap is one entry moved into place, and where the entry it replaced went
+type swap struct {
+	path   string
+	backup string // empty when nothing was there
+}
+
+func newInstallTx(ctx context.Context) *installTx {
+	return &installTx{ctx: ctx}
+}
+
+// stage creates a staging directory for one pack in an agent directory.
+// It's on the same filesystem, so swapping its entries in is a rename.
+func (tx *installTx) stage(root string) (string, error) {
+	return tx.stageIn(filepath.Join(root, installed.IndexDir))
+}
+
+// stageIn creates a staging directory in parent, for entries swapped in
+// on its filesystem
+func (tx *installTx) stageIn(parent string) (string, error) {
+	if err := tx.interrupted(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(parent, ".stage-")
+	if err != nil {
+		return "", err
+	}
+	tx.stages = append(tx.stages, dir)
+	return dir, nil
+}
+
+// replace swaps staged into path, moving what was there aside into the
+// stage. An empty staged only moves path aside, removing it.
+func (tx *installTx) replace(stage, path, staged string) error {
+	if err := tx.interrupted(); err != nil {
+		return err
+	}
+
+	s := swap{path: path}
+	if _, err := os.Lstat(path); err == nil {
+		s.backup = filepath.Join(stage, ".old", strconv.Itoa(len(tx.swaps)))
+		if err := os.MkdirAll(filepath.Dir(s.backup), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(path, s.backup); err != nil {
+			return err
+		}
+	} else if staged == "" {
+		return nil
+	}
+	tx.swaps = append(tx.swaps, s)
+
+	if staged == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.Rename(staged, path)
+}
+
+// whenCommitted runs fn once the whole install is in place
+func (tx *installTx) whenCommitted(fn func()) {
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// Commit keeps everything swapped in and deletes what it replaced
+func (tx *installTx) Commit() error {
+	if err := tx.interrupted(); err != nil {
+		return err
+	}
+	tx.done = true
+	for _, dir := range tx.stages {
+		os.RemoveAll(dir)
+	}
+	for _, fn := range tx.onCommit {
+		fn()
+	}
+	return nil
+}
+
+// Rollback puts back everything that was replaced, newest first. It does
+// nothing after Commit, so it can be deferred.
+func (tx *installTx) Rollback() {
+	if tx.done {
+		return
+	}
+	tx.done = true
+	for i := len(tx.swaps) - 1; i >= 0; i-- {
+		s := tx.swaps[i]
+		os.RemoveAll(s.path)
+		if s.backup != "" {
+			os.Rename(s.backup, s.path)
+		}
+	}
+	for _, dir := range tx.stages {
+		os.RemoveAll(dir)
+		os.Remove(filepath.Dir(dir))
+	}
+}
+
+func (tx *installTx) interrupted() error {
+	return whenInterrupted(tx.ctx, nil)
+}
+
+// whenInterrupted reports err as an interruption when ctx was cancelled,
+// rather than as whatever the cancelled request failed with
+func whenInterrupted(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w; nothing was changed", errInterrupted)
+	}
+	return err
+}
+
+// carryOver links the files under src that keep into the same place under
+// dst, so swapping dst in doesn't take away files the user added. keep is
+// given paths relative to src.
+func carryOver(src, dst string, keep func(rel string) bool) error {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if !keep(filepath.ToSlash(rel)) {
+			return nil
+		}
+		return linkFile(path, filepath.Join(dst, rel))
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// linkFile hardlinks a file to a new name, copying it when that fails.
+// Symlinks are recreated as they are.
+func linkFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(dst, data, info.Mode().Perm())
+}
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tunajam/packs/internal/installed"
+)
+
+// writeTree writes files, by path relative to dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree reads every file under dir, by path relative to it
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func sameTree(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// swapAll stages a new hello, a new pack and the removal of old.md into
+// root, as an install replacing and adding packs would
+func swapAll(t *testing.T, tx *installTx, root string) error {
+	t.Helper()
+	stage, err := tx.stage(root)
+	if err != nil {
+		return err
+	}
+	writeTree(t, stage, map[string]string{
+		"hello/SKILL.md": "new hello\n",
+		"added/SKILL.md": "added\n",
+	})
+	if err := tx.replace(stage, filepath.Join(root, "hello"), filepath.Join(stage, "hello")); err != nil {
+		return err
+	}
+	if err := tx.replace(stage, filepath.Join(root, "added"), filepath.Join(stage, "added")); err != nil {
+		return err
+	}
+	return tx.replace(stage, filepath.Join(root, "old.md"), "")
+}
+
+var beforeInstall = map[string]string{
+	"hello/SKILL.md":     "old hello\n",
+	"hello/notes.md":     "old notes\n",
+	"old.md":             "old\n",
+	"unrelated/SKILL.md": "unrelated\n",
+}
+
+func TestInstallTxRollback(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, beforeInstall)
+
+	tx := newInstallTx(context.Background())
+	committed := false
+	tx.whenCommitted(func() { committed = true })
+	if err := swapAll(t, tx, root); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTree(t, root); got["hello/SKILL.md"] != "new hello\n" {
+		t.Fatalf("hello not swapped in: %v", got)
+	}
+
+	tx.Rollback()
+	if got := readTree(t, root); !sameTree(got, beforeInstall) {
+		t.Errorf("after Rollback:\n got  %v\n want %v", got, beforeInstall)
+	}
+	if _, err := os.Stat(filepath.Join(root, installed.IndexDir)); err == nil {
+		t.Error("staging left an index directory behind")
+	}
+	if committed {
+		t.Error("commit hook ran on rollback")
+	}
+}
+
+func TestInstallTxCommit(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, beforeInstall)
+
+	tx := newInstallTx(context.Background())
+	committed := false
+	tx.whenCommitted(func() { committed = true })
+	if err := swapAll(t, tx, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+
+	want := map[string]string{
+		"hello/SKILL.md":     "new hello\n",
+		"added/SKILL.md":     "added\n",
+		"unrelated/SKILL.md": "unrelated\n",
+	}
+	if got := readTree(t, root); !sameTree(got, want) {
+		t.Errorf("after Commit:\n got  %v\n want %v", got, want)
+	}
+	if !committed {
+		t.Error("commit hook didn't run")
+	}
+}
+
+func TestInstallTxInterrupted(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, beforeInstall)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx := newInstallTx(ctx)
+	if err := swapAll(t, tx, root); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	if _, err := tx.stage(root); !errors.Is(err, errInterrupted) {
+		t.Errorf("stage = %v, want errInterrupted", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, errInterrupted) {
+		t.Fatalf("Commit = %v, want errInterrupted", err)
+	}
+	tx.Rollback()
+	if got := readTree(t, root); !sameTree(got, beforeInstall) {
+		t.Errorf("after interrupted install:\n got  %v\n want %v", got, beforeInstall)
+	}
+}
+
+func TestCarryOver(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{
+		"SKILL.md":       "pack\n",
+		"notes/mine.md":  "mine\n",
+		"scripts/run.sh": "pack\n",
+	})
+	writeTree(t, dst, map[string]string{"SKILL.md": "new pack\n"})
+
+	keep := func(rel string) bool { return rel == "notes/mine.md" }
+	if err := carryOver(src, dst, keep); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"SKILL.md": "new pack\n", "notes/mine.md": "mine\n"}
+	if got := readTree(t, dst); !sameTree(got, want) {
+		t.Errorf("carryOver:\n got  %v\n want %v", got, want)
+	}
+
+	if err := carryOver(filepath.Join(src, "missing"), dst, keep); err != nil {
+		t.Errorf("carryOver from a missing directory: %v", err)
+	}
+}
@@ -78,6 +78,7 @@ type pack struct {
 	description string
 	packType    string
 	author      string
+	registry    string
 }
 
 // Messages for async operations
@@ -91,7 +92,7 @@ type packsErrorMsg struct {
 
 func fetchPacks(filter string) tea.Cmd {
 	return func() tea.Msg {
-		client := api.New()
+		store := newStore()
 		ctx := context.Background()
 		
 		packType := ""
@@ -99,7 +100,7 @@ func fetchPacks(filter string) tea.Cmd {
 			packType = filter
 		}
 		
-		results, _, err := client.Search(ctx, api.SearchOpts{
+		results, _, err := store.Search(ctx, api.SearchOpts{
 			Query: "",
 			Type:  packType,
 			Limit: 100,
@@ -118,6 +119,7 @@ func fetchPacks(filter string) tea.Cmd {
 				description: r.Description,
 				packType:    r.Type,
 				author:      r.Author,
+				registry:    r.Registry,
 			}
 		}
 		return packsLoadedMsg{packs: packs}
@@ -372,7 +374,7 @@ func (m model) View() string {
 		typeIcon := getTypeIcon(p.packType)
 
 		s.WriteString(fmt.Sprintf("  %s %s\n", typeIcon, titleStyle.Render(p.name)))
-		s.WriteString(fmt.Sprintf("  %s\n\n", dimStyle.Render(p.author)))
+		s.WriteString(fmt.Sprintf("  %s\n\n", dimStyle.Render(p.author+" · "+p.registry)))
 		s.WriteString(fmt.Sprintf("  %s\n\n", p.description))
 		s.WriteString(fmt.Sprintf("  ★ %d stars\n\n", p.stars))
 		s.WriteString(fmt.Sprintf("  %s\n\n", helpStyle.Render("Press ENTER or 'g' to install, ESC to go back")))
@@ -445,7 +447,7 @@ func truncateStr(s string, max int) string {
 	return s[:max-3] + "..."
 }
 
-func RunTUI() {
+func RunTUI(ctx context.Context) {
 	p := tea.NewProgram(initialModel())
 	m, err := p.Run()
 	if err != nil {
@@ -456,7 +458,7 @@ func RunTUI() {
 	// If a pack was selected, install it
 	if model, ok := m.(model); ok && model.selected != nil {
 		fmt.Printf("\n📦 Getting %s...\n\n", model.selected.name)
-		err := runGet(model.selected.name, "", false, false)
+		err := runGet(ctx, model.selected.name, "", "", "", "", false, false, "", false, false, false)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
@@ -0,0 +1,589 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tunajam/packs/internal/agents"
+	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/diff"
+	"github.com/tunajam/packs/internal/installed"
+	packfmt "github.com/tunajam/packs/internal/pack"
+	"github.com/tunajam/packs/internal/packref"
+	packstore "github.com/tunajam/packs/internal/store"
+)
+
+func OutdatedCmd() *cobra.Command {
+	var agentFlag string
+	var jsonFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "Show installed packs with newer versions",
+		Long: `Compare every installed pack with what its source offers now.
+
+  CURRENT   Installed version (or GitHub commit)
+  WANTED    Newest version the pack's constraint allows; 'packs update'
+            installs this
+  LATEST    Newest version overall
+
+Registry packs are checked against the registry's version list, GitHub
+packs against the branch or tag they were installed from, and archives by
+re-reading them. The constraint is the one recorded at install: the range
+you asked for, or ^<version> for a plain 'packs get'.
+
+FLAGS:
+  -a, --agent <name>   Only packs for one agent
+  -j, --json           Output as JSON
+
+EXAMPLES:
+  packs outdated                # Everything installed
+  packs outdated --agent claude
+  packs update                  # Install the WANTED versions`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runOutdated(cmd.Context(), agentFlag, jsonFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentFlag, "agent", "a", "", "Filter by agent")
+	cmd.Flags().BoolVarP(&jsonFlag, "json", "j", false, "Output as JSON")
+
+	return cmd
+}
+
+func UpdateCmd() *cobra.Command {
+	var agentFlag string
+	var forceFlag bool
+	var dryRunFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "update [pack...]",
+		Short: "Update installed packs within their constraints",
+		Long: `Update installed packs to the newest version their constraint allows.
+
+Each update lists the files it adds (+), changes (~) and removes (-).
+
+Local edits carry over: each install keeps a copy of the files as upstream
+shipped them, and an update merges your changes since then with upstream's
+(a three-way merge, like git's). Packs whose edits conflict with the new
+version are skipped and their conflicting files listed, so local changes
+are never lost silently, and the update exits non-zero; 'packs diff <pack>'
+shows them, and --force overwrites them. Each pack is swapped in whole: an update that fails or is
+interrupted leaves that pack as it was.
+
+FLAGS:
+  -a, --agent <name>   Only packs for one agent
+  -n, --dry-run        Show what would change without writing anything
+  -f, --force          Overwrite local edits instead of merging them
+
+EXAMPLES:
+  packs update                  # Update everything
+  packs update convex docx      # Update some packs
+  packs update --dry-run        # Preview
+  packs outdated                # See what's behind first`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdate(cmd.Context(), args, agentFlag, forceFlag, dryRunFlag)
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentFlag, "agent", "a", "", "Filter by agent")
+	cmd.Flags().BoolVarP(&dryRunFlag, "dry-run", "n", false, "Show changes without writing")
+	cmd.Flags().BoolVarP(&forceFlag, "force", "f", false, "Overwrite local edits instead of merging them")
+
+	return cmd
+}
+
+// packUpdate is what an installed pack's source offers now
+type packUpdate struct {
+	Name    string `json:"name"`
+	Agent   string `json:"agent"`
+	Dir     string `json:"dir"`
+	Current string `json:"current"`
+	Wanted  string `json:"wanted"`
+	Latest  string `json:"latest"`
+	Error   string `json:"error,omitempty"`
+
+	pack *installed.Pack
+	ref  packref.PackRef // what to fetch for the wanted version
+	pre  *fetchedPack    // archives are fetched to be checked
+}
+
+// Outdated reports whether the wanted version differs from the installed one
+func (u *packUpdate) Outdated() bool {
+	return u.Error == "" && u.Wanted != "" && u.Wanted != u.Current
+}
+
+// installedSpec is the constraint an installed pack updates within. Packs
+// installed before specs were recorded stay within their major version.
+func installedSpec(p *installed.Pack) string {
+	switch {
+	case p.Spec != "":
+		return p.Spec
+	case p.Source == "github", p.Source == "git", p.Source == "local", p.Source == "archive":
+		return p.Ref
+	case api.IsExactVersion(p.Version):
+		return "^" + p.Version
+	default:
+		return "latest"
+	}
+}
+
+// checkUpdate asks an installed pack's source for its newest versions
+func checkUpdate(ctx context.Context, p *installed.Pack) *packUpdate {
+	u := &packUpdate{Name: p.Name, Agent: p.Agent, Dir: p.Dir, pack: p}
+	var err error
+	switch p.Source {
+	case "github":
+		err = checkGitHubUpdate(ctx, u)
+	case "git":
+		err = checkGitUpdate(ctx, u)
+	case "archive", "local":
+		err = checkArchiveUpdate(ctx, u)
+	default:
+		err = checkRegistryUpdate(ctx, u)
+	}
+	if err != nil {
+		u.Error = err.Error()
+	}
+	return u
+}
+
+func checkRegistryUpdate(ctx context.Context, u *packUpdate) error {
+	p := u.pack
+	u.Current = p.Version
+	spec := installedSpec(p)
+
+	registries := newRegistries("")
+	var store api.PackStore = registries
+	if s := registries.Store(p.Registry); s != nil {
+		store = s
+	}
+
+	name := p.Name
+	if p.Pack != "" {
+		name = p.Pack
+	}
+	versions, err := store.ListVersions(ctx, name)
+	if err != nil || len(versions) == 0 {
+		// Registries without version lists only know their latest
+		latest, err := store.Get(ctx, name, "")
+		if err != nil {
+			return err
+		}
+		versions = []string{latest.Version}
+	}
+
+	u.Latest, _ = api.MatchVersion(versions, "latest", false)
+	u.Wanted, err = api.MatchVersion(versions, spec, false)
+	if errors.Is(err, api.ErrNoMatchingVersion) {
+		// Nothing newer within the constraint
+		u.Wanted = u.Current
+	} else if err != nil {
+		return err
+	}
+	u.ref = packref.PackRef{Kind: packref.Registry, Name: name, Version: u.Wanted}
+	return nil
+}
+
+func checkGitHubUpdate(ctx context.Context, u *packUpdate) error {
+	p := u.pack
+	if offlineFlag {
+		return fmt.Errorf("GitHub packs are not available offline: %s", p.Ref)
+	}
+	u.Current = shortCommit(p.Commit)
+
+	r, err := packref.Parse(installedSpec(p))
+	if err != nil {
+		return err
+	}
+	src := githubSourceOf(r)
+	commit, err := resolveGitHubCommit(ctx, src)
+	if err != nil {
+		return err
+	}
+	u.Wanted = shortCommit(commit)
+	u.Latest = u.Wanted
+	u.ref = r
+	u.ref.Rev = commit
+
+	// Packs pinned to a release tag stay on it; report newer releases
+	if api.IsExactVersion(strings.TrimPrefix(src.Ref, "v")) {
+		if commit == p.Commit {
+			u.Current = src.Ref
+		}
+		u.Wanted = src.Ref
+		u.Latest = src.Ref
+		if tags, err := listGitHubTags(ctx, src); err == nil {
+			if latest, err := api.MatchVersion(tags, "latest", false); err == nil {
+				u.Latest = latest
+			}
+		}
+	}
+	return nil
+}
+
+func checkGitUpdate(ctx context.Context, u *packUpdate) error {
+	p := u.pack
+	if offlineFlag {
+		return fmt.Errorf("git packs are not available offline: %s", p.Ref)
+	}
+	u.Current = shortCommit(p.Commit)
+
+	r, err := packref.Parse(installedSpec(p))
+	if err != nil {
+		return err
+	}
+	commit, err := resolveGitCommit(ctx, r)
+	if err != nil {
+		return err
+	}
+	u.Wanted = shortCommit(commit)
+	u.Latest = u.Wanted
+	u.ref = r
+	u.ref.Rev = commit
+	return nil
+}
+
+// checkArchiveUpdate reads an archive or directory again to see whether
+// its contents changed
+func checkArchiveUpdate(ctx context.Context, u *packUpdate) error {
+	p := u.pack
+	u.Current = archiveVersion(p.Version, p.ContentHash)
+
+	r, err := packref.Parse(p.Ref)
+	if err != nil {
+		return err
+	}
+	r = fromProject(r)
+	fetched, err := fetchPack(ctx, r, false)
+	if err != nil {
+		return err
+	}
+	u.Wanted = archiveVersion(fetched.Locked.Version, fetched.Locked.ContentHash)
+	u.Latest = u.Wanted
+	u.ref = r
+	u.pre = fetched
+	return nil
+}
+
+// archiveVersion labels archive contents by version and short digest, so an
+// archive republished under the same version still shows as changed
+func archiveVersion(version, digest string) string {
+	short := strings.TrimPrefix(digest, "sha256:")
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	if version == "" {
+		return short
+	}
+	return version + "+" + short
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	if commit == "" {
+		return "-"
+	}
+	return commit
+}
+
+func runOutdated(ctx context.Context, agent string, jsonOutput bool) error {
+	packs, err := installedPacks(agent, "", "")
+	if err != nil {
+		return err
+	}
+
+	var updates []*packUpdate
+	for _, p := range packs {
+		u := checkUpdate(ctx, p)
+		if u.Error != "" || u.Outdated() || (u.Latest != "" && u.Latest != u.Current) {
+			updates = append(updates, u)
+		}
+	}
+
+	if jsonOutput {
+		if updates == nil {
+			updates = []*packUpdate{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(updates)
+	}
+
+	if len(updates) == 0 {
+		fmt.Printf("✓ All %d installed packs are up to date\n", len(packs))
+		return nil
+	}
+
+	fmt.Printf("\n  %-24s %-12s %-12s %-12s %s\n", "PACK", "CURRENT", "WANTED", "LATEST", "AGENT")
+	for _, u := range updates {
+		if u.Error != "" {
+			fmt.Printf("  %-24s ! %s\n", truncate(u.Name, 24), u.Error)
+			continue
+		}
+		fmt.Printf("  %-24s %-12s %-12s %-12s %s\n",
+			truncate(u.Name, 24), u.Current, u.Wanted, u.Latest, u.Agent)
+	}
+	fmt.Printf("\n  Run: packs update to install the wanted versions\n\n")
+	return nil
+}
+
+func runUpdate(ctx context.Context, names []string, agent string, force, dryRun bool) error {
+	packs, err := installedPacks(agent, "", "")
+	if err != nil {
+		return err
+	}
+
+	if len(names) > 0 {
+		byName := map[string][]*installed.Pack{}
+		for _, p := range packs {
+			byName[p.Name] = append(byName[p.Name], p)
+		}
+		packs = nil
+		for _, name := range names {
+			if len(byName[name]) == 0 {
+				return fmt.Errorf("pack not installed: %s\nSee installed packs with: packs list", name)
+			}
+			packs = append(packs, byName[name]...)
+		}
+	}
+
+	updated, skipped, failed := 0, 0, 0
+	for _, p := range packs {
+		u := checkUpdate(ctx, p)
+		if u.Error != "" {
+			failed++
+			fmt.Printf("  ✗ %s: %s\n", p.Name, u.Error)
+			continue
+		}
+		if !u.Outdated() {
+			fmt.Printf("  ✓ %s is up to date (%s)\n", p.Name, u.Current)
+			continue
+		}
+
+		if err := applyUpdate(ctx, u, dryRun, force); err != nil {
+			// Each pack updates on its own; the ones done so far stay
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w after updating %d packs; %s was left as it was", errInterrupted, updated, p.Name)
+			}
+			if errors.Is(err, errLocalEdits) {
+				skipped++
+				fmt.Printf("  ! %s %v; skipped\n", p.Name, err)
+				fmt.Printf("    See them with: packs diff %s, or use --force to overwrite them\n", p.Name)
+				continue
+			}
+			failed++
+			fmt.Printf("  ✗ %s: %v\n", p.Name, err)
+			continue
+		}
+		updated++
+	}
+
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	if failed == 0 && skipped == 0 {
+		fmt.Printf("\n✓ %s %d packs\n", verb, updated)
+		return nil
+	}
+
+	// The summary is the error, so a run that left packs behind exits
+	// non-zero without a ✓
+	fmt.Println()
+	var left []string
+	if failed > 0 {
+		left = append(left, fmt.Sprintf("%d failed", failed))
+	}
+	hint := "See the errors above"
+	if skipped > 0 {
+		left = append(left, fmt.Sprintf("%d skipped with local edits", skipped))
+		hint = "Merge skipped packs by hand, or use --force to overwrite them"
+	}
+	return fmt.Errorf("%s %d packs; %s\n%s", verb, updated, strings.Join(left, ", "), hint)
+}
+
+// errLocalEdits is returned when an update would lose local edits
+var errLocalEdits = errors.New("has local edits")
+
+// applyUpdate fetches the wanted version, merges local edits into it unless
+// force, prints its file changes and, unless dryRun, replaces the installed
+// files
+func applyUpdate(ctx context.Context, u *packUpdate, dryRun, force bool) error {
+	p := u.pack
+	fetched := u.pre
+	if fetched == nil {
+		var err error
+		if fetched, err = fetchPack(ctx, u.ref, false); err != nil {
+			return err
+		}
+	}
+	fetched.Locked.Name = p.Name
+	fetched.Locked.Spec = installedSpec(p)
+	fetched.Pack = p.Pack
+
+	loc := locationFor(p)
+	files, _, err := layoutFor(loc, fetched)
+	if err != nil {
+		return err
+	}
+	old, err := p.ReadFiles()
+	if err != nil {
+		return err
+	}
+
+	var merged map[string][]byte
+	if !force {
+		if merged, err = keepEdits(ctx, loc, p, files, u.Wanted); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("  ↑ %s %s → %s\n", p.Name, u.Current, u.Wanted)
+	printFileChanges(old, files, merged)
+	if dryRun {
+		return nil
+	}
+
+	// Files the user added aren't the old version's to take away, so only
+	// what the last install wrote is removed
+	tx := newInstallTx(ctx)
+	defer tx.Rollback()
+	if _, err := installTarget(tx, loc, fetched, installOpts{force: true, merged: merged, copy: p.Link == string(packstore.Copy)}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// keepEdits merges the local edits to an installed pack into the files
+// replacing it, returned as mergeEdits does. Edits that conflict with them
+// fail with errLocalEdits.
+func keepEdits(ctx context.Context, loc agents.Location, p *installed.Pack, files []packfmt.File, version string) (map[string][]byte, error) {
+	if !p.Modified {
+		return nil, nil
+	}
+	old, err := p.ReadFiles()
+	if err != nil {
+		return nil, err
+	}
+	base, err := upstreamFiles(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("%w, and %v", errLocalEdits, err)
+	}
+	merged, conflicts := mergeEdits(loc, p.Name, base, old, files, version)
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w that conflict with %s in %s", errLocalEdits, version, strings.Join(conflicts, ", "))
+	}
+	return merged, nil
+}
+
+// mergeEdits merges the local edits to each file, made since upstream
+// shipped it as base, into the incoming version. It returns the files that
+// differ from the incoming version once merged, and the ones where edits
+// and upstream changes conflict. A file deleted locally is an edit too: it
+// stays deleted, as a nil entry, unless upstream changed it, which is a
+// conflict. So is a file edited locally that the incoming version drops.
+func mergeEdits(loc agents.Location, name string, base, local, incoming []packfmt.File, version string) (map[string][]byte, []string) {
+	upstream := map[string][]byte{}
+	for _, f := range base {
+		upstream[f.Path] = f.Content
+	}
+	now := map[string][]byte{}
+	for _, f := range local {
+		now[f.Path] = f.Content
+	}
+
+	merged := map[string][]byte{}
+	var conflicts []string
+	for _, f := range incoming {
+		theirs := incomingContent(loc, name, f)
+		mine, edited := now[f.Path]
+		orig, known := upstream[f.Path]
+		delete(now, f.Path)
+		switch {
+		case !edited && !known:
+			// New upstream
+		case !edited && bytes.Equal(orig, theirs):
+			merged[f.Path] = nil
+		case !edited:
+			conflicts = append(conflicts, f.Path)
+		case known && bytes.Equal(mine, orig), bytes.Equal(mine, theirs):
+			// Nothing of ours to keep
+		case known && bytes.Equal(orig, theirs):
+			merged[f.Path] = mine
+		default:
+			content, n := diff.Merge(orig, mine, theirs, "local", name+" as installed", name+" "+version)
+			if n > 0 {
+				conflicts = append(conflicts, f.Path)
+			}
+			merged[f.Path] = content
+		}
+	}
+	for path, mine := range now {
+		if orig, known := upstream[path]; !known || !bytes.Equal(mine, orig) {
+			conflicts = append(conflicts, path)
+		}
+	}
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
+
+// printFileChanges lists added, changed and removed files. Files in merged
+// get that content instead of the new version's.
+func printFileChanges(old, new []packfmt.File, merged map[string][]byte) {
+	before := map[string]packfmt.File{}
+	for _, f := range old {
+		before[f.Path] = f
+	}
+
+	for _, f := range new {
+		prev, ok := before[f.Path]
+		content, isMerged := merged[f.Path]
+		if !isMerged {
+			content = f.Content
+		}
+		switch {
+		case isMerged && content == nil:
+			fmt.Printf("      - %s stays deleted, as you left it\n", f.Path)
+		case !ok:
+			fmt.Printf("      + %s\n", f.Path)
+		case !bytes.Equal(prev.Content, content) || prev.Mode != packfmt.NormalizeMode(f.Mode):
+			if isMerged {
+				fmt.Printf("      ~ %s, with your edits merged in\n", f.Path)
+			} else {
+				fmt.Printf("      ~ %s\n", f.Path)
+			}
+		}
+		delete(before, f.Path)
+	}
+
+	for _, f := range old {
+		if _, ok := before[f.Path]; ok {
+			fmt.Printf("      - %s\n", f.Path)
+		}
+	}
+}
+
+// removeFile deletes an installed file and any directories it leaves empty
+// below root
+func removeFile(root, rel string) {
+	root = filepath.Clean(root)
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	os.Remove(path)
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tunajam/packs/internal/agents"
+	packfmt "github.com/tunajam/packs/internal/pack"
+)
+
+// files builds a file set from path=content pairs; an absent file is left
+// out
+func files(pairs ...string) []packfmt.File {
+	var fs []packfmt.File
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fs = append(fs, packfmt.File{Path: pairs[i], Mode: 0644, Content: []byte(pairs[i+1])})
+	}
+	return fs
+}
+
+func TestMergeEdits(t *testing.T) {
+	tests := []struct {
+		name                  string
+		base, local, incoming []packfmt.File
+		merged                map[string]string // "<nil>" for a file left out
+		conflicts             []string
+	}{
+		{
+			name:     "no edits",
+			base:     files("a.md", "a\n"),
+			local:    files("a.md", "a\n"),
+			incoming: files("a.md", "A\n"),
+		},
+		{
+			name:     "edit kept over an unchanged file",
+			base:     files("a.md", "a\n", "b.md", "b\n"),
+			local:    files("a.md", "mine\n", "b.md", "b\n"),
+			incoming: files("a.md", "a\n", "b.md", "B\n"),
+			merged:   map[string]string{"a.md": "mine\n"},
+		},
+		{
+			name:     "edit merged with an upstream change",
+			base:     files("a.md", "1\n2\n3\n4\n"),
+			local:    files("a.md", "one\n2\n3\n4\n"),
+			incoming: files("a.md", "1\n2\n3\nfour\n"),
+			merged:   map[string]string{"a.md": "one\n2\n3\nfour\n"},
+		},
+		{
+			name:      "edit conflicting with an upstream change",
+			base:      files("a.md", "a\n"),
+			local:     files("a.md", "mine\n"),
+			incoming:  files("a.md", "theirs\n"),
+			conflicts: []string{"a.md"},
+		},
+		{
+			name:     "deleted file stays deleted",
+			base:     files("a.md", "a\n", "b.md", "b\n"),
+			local:    files("a.md", "a\n"),
+			incoming: files("a.md", "A\n", "b.md", "b\n"),
+			merged:   map[string]string{"b.md": "<nil>"},
+		},
+		{
+			name:      "deleted file changed upstream",
+			base:      files("a.md", "a\n", "b.md", "b\n"),
+			local:     files("a.md", "a\n"),
+			incoming:  files("a.md", "a\n", "b.md", "B\n"),
+			conflicts: []string{"b.md"},
+		},
+		{
+			name:     "new upstream file",
+			base:     files("a.md", "a\n"),
+			local:    files("a.md", "mine\n"),
+			incoming: files("a.md", "a\n", "new.md", "new\n"),
+			merged:   map[string]string{"a.md": "mine\n"},
+		},
+		{
+			name:      "edited file dropped upstream",
+			base:      files("a.md", "a\n", "b.md", "b\n"),
+			local:     files("a.md", "a\n", "b.md", "mine\n"),
+			incoming:  files("a.md", "a\n"),
+			conflicts: []string{"b.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := mergeEdits(agents.Location{}, "hello", tt.base, tt.local, tt.incoming, "2.0.0")
+			if strings.Join(conflicts, ",") != strings.Join(tt.conflicts, ",") {
+				t.Fatalf("conflicts = %v, want %v", conflicts, tt.conflicts)
+			}
+			if len(tt.conflicts) > 0 {
+				return
+			}
+			if len(merged) != len(tt.merged) {
+				t.Fatalf("merged %d files, want %d: %q", len(merged), len(tt.merged), merged)
+			}
+			for path, want := range tt.merged {
+				got, ok := merged[path]
+				switch {
+				case !ok:
+					t.Fatalf("%s not merged", path)
+				case want == "<nil>" && got != nil:
+					t.Fatalf("%s = %q, want it left out", path, got)
+				case want != "<nil>" && string(got) != want:
+					t.Fatalf("%s = %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,669 @@
+// Package config loads packs configuration from layered sources.
+//
+// Values are resolved in this order, later layers winning:
+//
+//  1. Built-in defaults
+//  2. User config:    ~/.packs/config.yaml
+//  3. Project config: .packs/config.yaml in the current directory or the
+//     nearest parent that has one
+//  4. Environment variables (PACKS_REGISTRY, PACKS_SKILLS_DIR, ...)
+//  5. Command-line flags (--registry, ...)
+//
+// Every effective value remembers which layer it came from.
+//
+// A project config comes with the repo, so it's trusted less than the
+// user's own: it can make signing.policy stricter but not looser, and its
+// trusted_keys, allow_unsigned and registry auth are ignored unless the
+// user sets trust_project_config.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tunajam/packs/internal/api"
+	"github.com/tunajam/packs/internal/signing"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPriority is the implicit priority of packs.sh. Registries with a
+// lower priority are checked first.
+const DefaultPriority = 99
+
+// Source is the layer a config value came from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceUser    Source = "user"
+	SourceProject Source = "project"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Key describes a supported config key
+type Key struct {
+	Name     string
+	Default  string
+	Env      []string // env vars that override the key, first set wins
+	Help     string
+	validate func(string) error
+}
+
+// Keys are the supported scalar config keys, in display order
+var Keys = []Key{
+	{Name: "registry", Default: api.DefaultBaseURL, Env: []string{"PACKS_REGISTRY", api.EnvBaseURL},
+		Help: "Default registry URL or local registry directory"},
+	{Name: "skills_dir", Env: []string{"PACKS_SKILLS_DIR"},
+		Help: "Where to install packs (auto-detected when empty)"},
+	{Name: "telemetry", Default: "true", Env: []string{"PACKS_TELEMETRY"},
+		Help: "Send anonymous install counts", validate: validateBool},
+	{Name: "cache.dir", Default: "~/.packs/cache", Env: []string{"PACKS_CACHE_DIR"},
+		Help: "Local cache directory"},
+	{Name: "cache.ttl", Default: "1h", Env: []string{"PACKS_CACHE_TTL"},
+		Help: "How long cached registry data stays fresh", validate: validateDuration},
+	{Name: "cache.max_size", Default: "100MB", Env: []string{"PACKS_CACHE_MAX_SIZE"},
+		Help: "Maximum cache size", validate: validateSize},
+	{Name: "store.dir", Default: "~/.packs/store", Env: []string{"PACKS_STORE_DIR"},
+		Help: "Store installed packs link to"},
+	{Name: "ui.color", Default: "auto", Env: []string{"PACKS_COLOR"},
+		Help: "Color output: auto, always, never", validate: validateOneOf("auto", "always", "never")},
+	{Name: "signing.policy", Default: "verify", Env: []string{"PACKS_SIGNING_POLICY"},
+		Help: "Signature policy: off, verify, require", validate: validateOneOf(policies...)},
+	{Name: "trust_project_config", Default: "false", Env: []string{"PACKS_TRUST_PROJECT_CONFIG"},
+		Help: "Let project config add signing keys and send registry auth", validate: validateBool},
+}
+
+// policies are the signing policies, weakest first
+var policies = []string{"off", "verify", "require"}
+
+func policyRank(p string) int {
+	for i, policy := range policies {
+		if p == policy {
+			return i
+		}
+	}
+	return -1
+}
+
+// Registry is a pack registry entry in config.yaml
+type Registry struct {
+	Name     string `yaml:"name,omitempty"`
+	URL      string `yaml:"url"`
+	Priority int    `yaml:"priority,omitempty"`
+	Auth     string `yaml:"auth,omitempty"` // "bearer <token>", may reference $ENV_VARS
+}
+
+// Token returns the bearer token from Auth with env vars expanded
+func (r Registry) Token() string {
+	auth := strings.TrimSpace(os.ExpandEnv(r.Auth))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		auth = strings.TrimSpace(auth[7:])
+	}
+	return auth
+}
+
+// Value is an effective config value and where it came from
+type Value struct {
+	Key    string
+	Value  string
+	Source Source
+	Origin string // file path, env var or flag name
+}
+
+// Config is the merged configuration
+type Config struct {
+	values map[string]Value
+
+	// Registries from the user and project config files
+	Registries []Registry
+
+	// TrustedKeys and AllowUnsigned from the user and project config files
+	TrustedKeys   []signing.TrustedKey
+	AllowUnsigned []string
+
+	// UserPath and ProjectPath are the config files that were considered.
+	// ProjectPath is empty outside a project.
+	UserPath    string
+	ProjectPath string
+
+	// Warnings are settings that were ignored, like project config the
+	// user hasn't trusted
+	Warnings []string
+}
+
+// file is the on-disk shape of config.yaml beyond the scalar keys
+type file struct {
+	Registries  []Registry           `yaml:"registries,omitempty"`
+	TrustedKeys []signing.TrustedKey `yaml:"trusted_keys,omitempty"`
+	Signing     struct {
+		AllowUnsigned []string `yaml:"allow_unsigned,omitempty"`
+	} `yaml:"signing,omitempty"`
+}
+
+// flagValues are overrides registered by command-line flags
+var flagValues = map[string]Value{}
+
+// SetFlag records a command-line flag override for a key
+func SetFlag(key, value, flag string) {
+	flagValues[key] = Value{Key: key, Value: value, Source: SourceFlag, Origin: flag}
+}
+
+// Dir returns the packs home directory (~/.packs)
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".packs")
+}
+
+// Path returns the user config file path
+func Path() string {
+	return filepath.Join(Dir(), "config.yaml")
+}
+
+// ProjectPath returns .packs/config.yaml in the current directory or the
+// nearest parent that has one, or "" when there is none. The home directory
+// is skipped since ~/.packs is the user config.
+func ProjectPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+	for {
+		if dir != home {
+			path := filepath.Join(dir, ".packs", "config.yaml")
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load resolves every layer into an effective config
+func Load() (*Config, error) {
+	cfg := &Config{
+		values:      map[string]Value{},
+		UserPath:    Path(),
+		ProjectPath: ProjectPath(),
+	}
+
+	for _, k := range Keys {
+		cfg.values[k.Name] = Value{Key: k.Name, Value: k.Default, Source: SourceDefault}
+	}
+
+	var errs []error
+	if err := cfg.loadFile(cfg.UserPath, SourceUser); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.ProjectPath != "" {
+		if err := cfg.loadFile(cfg.ProjectPath, SourceProject); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, k := range Keys {
+		for _, env := range k.Env {
+			v := os.Getenv(env)
+			if v == "" {
+				continue
+			}
+			if err := Validate(k.Name, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", env, err))
+			} else {
+				cfg.values[k.Name] = Value{Key: k.Name, Value: v, Source: SourceEnv, Origin: env}
+			}
+			break
+		}
+	}
+	// PACKS_NO_TELEMETRY=1 predates PACKS_TELEMETRY and still works
+	if os.Getenv("PACKS_NO_TELEMETRY") == "1" {
+		cfg.values["telemetry"] = Value{Key: "telemetry", Value: "false", Source: SourceEnv, Origin: "PACKS_NO_TELEMETRY"}
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		cfg.values["ui.color"] = Value{Key: "ui.color", Value: "never", Source: SourceEnv, Origin: "NO_COLOR"}
+	}
+
+	for key, v := range flagValues {
+		cfg.values[key] = v
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// trustsProject reports whether the user lets project config add signing
+// keys and registry auth. Only the user config and environment decide.
+func (c *Config) trustsProject() bool {
+	for _, env := range LookupKey("trust_project_config").Env {
+		if v := os.Getenv(env); v != "" {
+			b, _ := strconv.ParseBool(v)
+			return b
+		}
+	}
+	return c.Bool("trust_project_config")
+}
+
+// Default returns a config with only built-in defaults
+func Default() *Config {
+	cfg := &Config{values: map[string]Value{}, UserPath: Path()}
+	for _, k := range Keys {
+		cfg.values[k.Name] = Value{Key: k.Name, Value: k.Default, Source: SourceDefault}
+	}
+	return cfg
+}
+
+func (c *Config) loadFile(path string, source Source) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	flat := map[string]string{}
+	flatten("", raw, flat)
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		v := flat[key]
+		if LookupKey(key) == nil {
+			continue
+		}
+		// Invalid values keep the layer below, rather than becoming zero
+		if err := Validate(key, v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if source == SourceProject {
+			switch key {
+			case "trust_project_config":
+				c.warn("%s: ignoring trust_project_config; only your own config can set it", path)
+				continue
+			case "signing.policy":
+				if current := c.Get(key); policyRank(v) < policyRank(current) {
+					c.warn("%s: ignoring signing.policy %s; project config can make it stricter than %s, not looser", path, v, current)
+					continue
+				}
+			}
+		}
+		c.values[key] = Value{Key: key, Value: v, Source: source, Origin: path}
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if source == SourceProject && !c.trustsProject() {
+		c.dropUntrusted(path, &f)
+	}
+	c.Registries = append(c.Registries, f.Registries...)
+	c.TrustedKeys = append(c.TrustedKeys, f.TrustedKeys...)
+	c.AllowUnsigned = append(c.AllowUnsigned, f.Signing.AllowUnsigned...)
+	return errors.Join(errs...)
+}
+
+// dropUntrusted drops what an untrusted project config can't do: trust
+// keys, allow unsigned packs, or send credentials to its registries
+func (c *Config) dropUntrusted(path string, f *file) {
+	hint := "set trust_project_config: true in " + Path() + " to allow it"
+	if len(f.TrustedKeys) > 0 {
+		c.warn("%s: ignoring trusted_keys from project config; %s", path, hint)
+		f.TrustedKeys = nil
+	}
+	if len(f.Signing.AllowUnsigned) > 0 {
+		c.warn("%s: ignoring signing.allow_unsigned from project config; %s", path, hint)
+		f.Signing.AllowUnsigned = nil
+	}
+	for i, r := range f.Registries {
+		if r.Auth != "" {
+			c.warn("%s: not sending auth to %s from project config; %s", path, r.URL, hint)
+			f.Registries[i].Auth = ""
+		}
+	}
+}
+
+func (c *Config) warn(format string, args ...any) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf(format, args...))
+}
+
+// AuthAllowed reports whether the default registry may be sent the user's
+// login token: not when an untrusted project config picked it
+func (c *Config) AuthAllowed() bool {
+	return c.values["registry"].Source != SourceProject || c.trustsProject()
+}
+
+// ReadRegistries reads the registries list from a single config file
+func ReadRegistries(path string) ([]Registry, error) {
+	f, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.Registries, nil
+}
+
+// ReadTrustedKeys reads the trusted_keys list from a single config file
+func ReadTrustedKeys(path string) ([]signing.TrustedKey, error) {
+	f, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.TrustedKeys, nil
+}
+
+func readFile(path string) (*file, error) {
+	var f file
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+func flatten(prefix string, m map[string]any, out map[string]string) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(key, v, out)
+		case []any, nil:
+			// Lists (registries) are decoded separately
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// Lookup returns the effective value of a key
+func (c *Config) Lookup(key string) (Value, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// Get returns the effective value of a key, or ""
+func (c *Config) Get(key string) string {
+	return c.values[key].Value
+}
+
+// Values returns every effective value in Keys order
+func (c *Config) Values() []Value {
+	var values []Value
+	for _, k := range Keys {
+		values = append(values, c.values[k.Name])
+	}
+	return values
+}
+
+// Bool returns a boolean key
+func (c *Config) Bool(key string) bool {
+	b, _ := strconv.ParseBool(c.Get(key))
+	return b
+}
+
+// Duration returns a duration key, or 0 if it doesn't parse
+func (c *Config) Duration(key string) time.Duration {
+	d, _ := time.ParseDuration(c.Get(key))
+	return d
+}
+
+// Size returns a size key in bytes, or 0 if it doesn't parse
+func (c *Config) Size(key string) int64 {
+	n, _ := ParseSize(c.Get(key))
+	return n
+}
+
+// Path returns a path key with ~ expanded
+func (c *Config) Path(key string) string {
+	return ExpandHome(c.Get(key))
+}
+
+// Describe formats where a value came from, e.g. "env PACKS_REGISTRY"
+func (v Value) Describe() string {
+	if v.Origin == "" {
+		return string(v.Source)
+	}
+	return fmt.Sprintf("%s %s", v.Source, v.Origin)
+}
+
+// LookupKey returns the definition of a supported key, or nil
+func LookupKey(name string) *Key {
+	for i := range Keys {
+		if Keys[i].Name == name {
+			return &Keys[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks a value for a key before it is written
+func Validate(key, value string) error {
+	k := LookupKey(key)
+	if k == nil {
+		var names []string
+		for _, k := range Keys {
+			names = append(names, k.Name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown config key: %s\nValid keys: %s", key, strings.Join(names, ", "))
+	}
+	if k.validate != nil {
+		if err := k.validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// ExpandHome expands a leading ~ to the home directory
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return home + path[1:]
+	}
+	return path
+}
+
+// ParseSize parses sizes like "100MB", "1.5GB" or "4096"
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+	mult := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			mult = u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %q", s)
+	}
+	return int64(n * mult), nil
+}
+
+func validateBool(s string) error {
+	_, err := strconv.ParseBool(s)
+	return err
+}
+
+func validateDuration(s string) error {
+	_, err := time.ParseDuration(s)
+	return err
+}
+
+func validateSize(s string) error {
+	_, err := ParseSize(s)
+	return err
+}
+
+func validateOneOf(options ...string) func(string) error {
+	return func(s string) error {
+		for _, o := range options {
+			if s == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of: %s", strings.Join(options, ", "))
+	}
+}
+
+// Set writes a dotted key in a config file, keeping comments and the other
+// keys as they are. Scalar strings are written unquoted so YAML types them.
+func Set(path, key string, value any) error {
+	doc, err := readDoc(path)
+	if err != nil {
+		return err
+	}
+
+	var valueNode yaml.Node
+	if s, ok := value.(string); ok {
+		valueNode = yaml.Node{Kind: yaml.ScalarNode, Value: s}
+	} else if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child := mappingValue(node, part)
+		if child == nil || child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(node, part, child)
+		}
+		node = child
+	}
+	setMappingValue(node, parts[len(parts)-1], &valueNode)
+
+	return writeDoc(path, doc)
+}
+
+// Unset removes a dotted key from a config file. Parent mappings left empty
+// are removed too.
+func Unset(path, key string) error {
+	doc, err := readDoc(path)
+	if err != nil {
+		return err
+	}
+
+	if unsetPath(doc.Content[0], strings.Split(key, ".")) {
+		return writeDoc(path, doc)
+	}
+	return nil
+}
+
+func unsetPath(node *yaml.Node, parts []string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != parts[0] {
+			continue
+		}
+		if len(parts) == 1 {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+		child := node.Content[i+1]
+		if child.Kind != yaml.MappingNode || !unsetPath(child, parts[1:]) {
+			return false
+		}
+		if len(child.Content) == 0 {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		}
+		return true
+	}
+	return false
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value)
+}
+
+// readDoc parses a config file into a YAML document with a mapping root
+func readDoc(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode}
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid config %s: expected a mapping", path)
+	}
+	return &doc, nil
+}
+
+func writeDoc(path string, doc *yaml.Node) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	// Registry auth may live in here, so keep it private
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
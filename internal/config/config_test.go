@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadWith loads the config from a user and a project config file, in a
+// home of its own and with no PACKS_ environment set
+func loadWith(t *testing.T, user, project string) *Config {
+	t.Helper()
+	home := t.TempDir()
+	dir := filepath.Join(home, "project")
+	t.Setenv("HOME", home)
+	for _, k := range Keys {
+		for _, env := range k.Env {
+			t.Setenv(env, "")
+		}
+	}
+	t.Setenv("PACKS_NO_TELEMETRY", "")
+	t.Setenv("NO_COLOR", "")
+
+	for path, data := range map[string]string{
+		filepath.Join(home, ".packs", "config.yaml"): user,
+		filepath.Join(dir, ".packs", "config.yaml"):  project,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestLayers(t *testing.T) {
+	cfg := loadWith(t,
+		"cache:\n  ttl: 2h\n  max_size: 10MB\n",
+		"cache:\n  ttl: 5m\n",
+	)
+	t.Setenv("PACKS_CACHE_MAX_SIZE", "1MB")
+	envCfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cfg    *Config
+		key    string
+		value  string
+		source Source
+	}{
+		{cfg, "cache.dir", "~/.packs/cache", SourceDefault},
+		{cfg, "cache.max_size", "10MB", SourceUser},
+		{cfg, "cache.ttl", "5m", SourceProject},
+		{envCfg, "cache.max_size", "1MB", SourceEnv},
+		{envCfg, "cache.ttl", "5m", SourceProject},
+	}
+	for _, tt := range tests {
+		v, ok := tt.cfg.Lookup(tt.key)
+		if !ok || v.Value != tt.value || v.Source != tt.source {
+			t.Errorf("%s = %q from %s, want %q from %s", tt.key, v.Value, v.Source, tt.value, tt.source)
+		}
+	}
+}
+
+func TestInvalidValueKeepsLayerBelow(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(home)
+	path := filepath.Join(home, ".packs", "config.yaml")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("cache:\n  ttl: soon\n"), 0644)
+
+	cfg, err := Load()
+	if err == nil {
+		t.Error("Load accepted cache.ttl: soon")
+	}
+	if got := cfg.Get("cache.ttl"); got != "1h" {
+		t.Errorf("cache.ttl = %q, want the default 1h", got)
+	}
+}
+
+func TestProjectSigningPolicy(t *testing.T) {
+	tests := []struct {
+		user, project string
+		want          string
+		warns         bool
+	}{
+		{"", "off", "verify", true},
+		{"require", "verify", "require", true},
+		{"require", "off", "require", true},
+		{"", "require", "require", false},
+		{"off", "verify", "verify", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user+"->"+tt.project, func(t *testing.T) {
+			user := ""
+			if tt.user != "" {
+				user = "signing:\n  policy: " + tt.user + "\n"
+			}
+			cfg := loadWith(t, user, "signing:\n  policy: "+tt.project+"\n")
+			if got := cfg.Get("signing.policy"); got != tt.want {
+				t.Errorf("signing.policy = %q, want %q", got, tt.want)
+			}
+			if warned := len(cfg.Warnings) > 0; warned != tt.warns {
+				t.Errorf("warnings = %q", cfg.Warnings)
+			}
+		})
+	}
+}
+
+const projectOverreach = `trust_project_config: true
+trusted_keys:
+  - key: ed25519:AAAA
+signing:
+  allow_unsigned: ["*"]
+registries:
+  - name: evil
+    url: https://evil.example
+    auth: bearer $SECRET
+`
+
+func TestUntrustedProjectConfig(t *testing.T) {
+	cfg := loadWith(t, "trusted_keys:\n  - key: ed25519:BBBB\n", projectOverreach+"registry: https://evil.example\n")
+
+	if cfg.Bool("trust_project_config") {
+		t.Error("project config trusted itself")
+	}
+	if len(cfg.TrustedKeys) != 1 || cfg.TrustedKeys[0].Key != "ed25519:BBBB" {
+		t.Errorf("TrustedKeys = %v, want only the user's key", cfg.TrustedKeys)
+	}
+	if len(cfg.AllowUnsigned) != 0 {
+		t.Errorf("AllowUnsigned = %v, want none", cfg.AllowUnsigned)
+	}
+	if len(cfg.Registries) != 1 || cfg.Registries[0].Auth != "" {
+		t.Errorf("Registries = %+v, want the registry without auth", cfg.Registries)
+	}
+	if cfg.AuthAllowed() {
+		t.Error("login token allowed for a registry the project picked")
+	}
+	for _, want := range []string{"trust_project_config", "trusted_keys", "allow_unsigned", "not sending auth"} {
+		if !strings.Contains(strings.Join(cfg.Warnings, "\n"), want) {
+			t.Errorf("no warning about %s in %q", want, cfg.Warnings)
+		}
+	}
+}
+
+func TestTrustedProjectConfig(t *testing.T) {
+	cfg := loadWith(t, "trust_project_config: true\n", projectOverreach+"registry: https://evil.example\n")
+
+	if len(cfg.TrustedKeys) != 1 || len(cfg.AllowUnsigned) != 1 {
+		t.Errorf("TrustedKeys = %v, AllowUnsigned = %v, want the project's", cfg.TrustedKeys, cfg.AllowUnsigned)
+	}
+	if len(cfg.Registries) != 1 || cfg.Registries[0].Auth == "" {
+		t.Errorf("Registries = %+v, want the registry with auth", cfg.Registries)
+	}
+	if !cfg.AuthAllowed() {
+		t.Error("login token refused with trust_project_config set")
+	}
+}
+
+func TestUserRegistryKeepsAuth(t *testing.T) {
+	cfg := loadWith(t, "registry: https://packs.acme.corp\n", "")
+	if !cfg.AuthAllowed() {
+		t.Error("login token refused for the user's own registry")
+	}
+}
@@ -0,0 +1,562 @@
+// Package diff compares text line by line, to show how the files of an
+// installed pack differ from an incoming version, to combine the two with
+// conflict markers, and to merge local edits into a new version.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Op is what an edit does to a line
+type Op int
+
+// Ops
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of a diff
+type Edit struct {
+	Op   Op
+	Line string // with its newline, if it had one
+}
+
+// Context is how many unchanged lines Unified shows around a change
+const Context = 3
+
+// Split splits text into lines, each keeping its newline
+func Split(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines returns the edits that turn a into b, keeping as many lines as
+// possible
+func Lines(a, b []string) []Edit {
+	// Common prefix and suffix are cheap; the LCS table covers the rest
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var edits []Edit
+	for _, line := range a[:pre] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, lcs(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, line := range a[len(a)-suf:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	return edits
+}
+
+func lcs(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	table := make([][]int32, n+1)
+	for i := range table {
+		table[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var edits []Edit
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, Edit{Equal, a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			edits = append(edits, Edit{Delete, a[i]})
+			i++
+		default:
+			edits = append(edits, Edit{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		edits = append(edits, Edit{Delete, a[i]})
+	}
+	for ; j < m; j++ {
+		edits = append(edits, Edit{Insert, b[j]})
+	}
+	return edits
+}
+
+// Unified returns a unified diff from a to b, or "" when they're the same
+func Unified(aName, bName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	edits := Lines(Split(a), Split(b))
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(edits); {
+		// Find the next change and the hunk around it
+		first := start
+		for first < len(edits) && edits[first].Op == Equal {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		from := max(first-Context, start)
+		to := first
+		for to < len(edits) {
+			if edits[to].Op != Equal {
+				to++
+				continue
+			}
+			run := to
+			for run < len(edits) && edits[run].Op == Equal {
+				run++
+			}
+			if run == len(edits) || run-to > 2*Context {
+				to = min(to+Context, run)
+				break
+			}
+			to = run
+		}
+
+		aLine, bLine := 1, 1
+		for _, e := range edits[:from] {
+			if e.Op != Insert {
+				aLine++
+			}
+			if e.Op != Delete {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, e := range edits[from:to] {
+			if e.Op != Insert {
+				aCount++
+			}
+			if e.Op != Delete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, e := range edits[from:to] {
+			buf.WriteString(prefix(e.Op))
+			buf.WriteString(e.Line)
+			if !strings.HasSuffix(e.Line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return buf.String()
+}
+
+func hunkRange(line, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line-1)
+	case 1:
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+func prefix(op Op) string {
+	switch op {
+	case Delete:
+		return "-"
+	case Insert:
+		return "+"
+	}
+	return " "
+}
+
+// Conflict combines two versions of a file, keeping the lines they share
+// and marking every place they differ:
+//
+//	<<<<<<< mine
+//	…lines only in mine…
+//	=======
+//	…lines only in theirs…
+//	>>>>>>> theirs
+//
+// It reports how many places were marked.
+func Conflict(mine, theirs []byte, mineLabel, theirsLabel string) ([]byte, int) {
+	edits := Lines(Split(mine), Split(theirs))
+
+	var buf bytes.Buffer
+	conflicts := 0
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			buf.WriteString(edits[i].Line)
+			i++
+			continue
+		}
+		var ours, their []string
+		for ; i < len(edits) && edits[i].Op != Equal; i++ {
+			if edits[i].Op == Delete {
+				ours = append(ours, edits[i].Line)
+			} else {
+				their = append(their, edits[i].Line)
+			}
+		}
+		writeConflict(&buf, mineLabel, ours, "", nil, theirsLabel, their)
+		conflicts++
+	}
+	return buf.Bytes(), conflicts
+}
+
+// Merge is a three-way merge: it applies the changes from base to mine and
+// from base to theirs together. Where both changed the same lines
+// differently it keeps both, with base between them:
+//
+//	<<<<<<< mine
+//	…
+//	||||||| base
+//	…
+//	=======
+//	…
+//	>>>>>>> theirs
+//
+// It reports how many conflicts it marked; with none, the merge is clean.
+func Merge(base, mine, theirs []byte, mineLabel, baseLabel, theirsLabel string) ([]byte, int) {
+	orig := Split(base)
+	ours := changes(orig, Split(mine))
+	their := changes(orig, Split(theirs))
+
+	var buf bytes.Buffer
+	conflicts := 0
+	pos := 0
+	for len(ours) > 0 || len(their) > 0 {
+		// The next region starts at the earliest change and grows over every
+		// change on either side that overlaps or touches it
+		var inOurs, inTheirs []change
+		var start, end int
+		if len(their) == 0 || len(ours) > 0 && ours[0].start <= their[0].start {
+			start, end = ours[0].start, ours[0].end
+			inOurs, ours = append(inOurs, ours[0]), ours[1:]
+		} else {
+			start, end = their[0].start, their[0].end
+			inTheirs, their = append(inTheirs, their[0]), their[1:]
+		}
+		for {
+			if len(ours) > 0 && ours[0].start <= end {
+				end = max(end, ours[0].end)
+				inOurs, ours = append(inOurs, ours[0]), ours[1:]
+			} else if len(their) > 0 && their[0].start <= end {
+				end = max(end, their[0].end)
+				inTheirs, their = append(inTheirs, their[0]), their[1:]
+			} else {
+				break
+			}
+		}
+
+		for _, line := range orig[pos:start] {
+			buf.WriteString(line)
+		}
+		pos = end
+
+		a := apply(orig, start, end, inOurs)
+		b := apply(orig, start, end, inTheirs)
+		switch {
+		case len(inTheirs) == 0:
+			writeLines(&buf, a)
+		case len(inOurs) == 0:
+			writeLines(&buf, b)
+		case equal(a, b):
+			writeLines(&buf, a)
+		default:
+			writeConflict(&buf, mineLabel, a, baseLabel, orig[start:end], theirsLabel, b)
+			conflicts++
+		}
+	}
+	for _, line := range orig[pos:] {
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), conflicts
+}
+
+// change replaces the lines start to end of the base with lines
+type change struct {
+	start, end int
+	lines      []string
+}
+
+// changes lists what turns base into other, in order
+func changes(base, other []string) []change {
+	var cs []change
+	i := 0
+	edits := Lines(base, other)
+	for k := 0; k < len(edits); {
+		if edits[k].Op == Equal {
+			i++
+			k++
+			continue
+		}
+		c := change{start: i}
+		for ; k < len(edits) && edits[k].Op != Equal; k++ {
+			if edits[k].Op == Delete {
+				i++
+			} else {
+				c.lines = append(c.lines, edits[k].Line)
+			}
+		}
+		c.end = i
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// apply returns the lines start to end of base with changes applied
+func apply(base []string, start, end int, cs []change) []string {
+	var lines []string
+	pos := start
+	for _, c := range cs {
+		lines = append(lines, base[pos:c.start]...)
+		lines = append(lines, c.lines...)
+		pos = c.end
+	}
+	return append(lines, base[pos:end]...)
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeLines writes lines, ending the last one if it has no newline and
+// more might follow
+func writeLines(buf *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+}
+
+// writeConflict writes one conflict between markers, with the base section
+// when baseLabel is set
+func writeConflict(buf *bytes.Buffer, mineLabel string, mine []string, baseLabel string, base []string, theirsLabel string, theirs []string) {
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	writeSection := func(marker string, lines []string) {
+		buf.WriteString(marker)
+		buf.WriteByte('\n')
+		for _, line := range lines {
+			buf.WriteString(line)
+		}
+		if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+			buf.WriteByte('\n')
+		}
+	}
+	writeSection("<<<<<<< "+mineLabel, mine)
+	if baseLabel != "" {
+		writeSection("||||||| "+baseLabel, base)
+	}
+	writeSection("=======", theirs)
+	buf.WriteString(">>>>>>> " + theirsLabel + "\n")
+}
+
+// HasConflicts reports whether text still has conflict markers in it
+func HasConflicts(data []byte) bool {
+	for _, line := range Split(data) {
+		if strings.HasPrefix(line, "<<<<<<< ") {
+			return true
+		}
+	}
+	return false
+}
+
+// FilePatch is the part of a unified diff that changes one file
+type FilePatch struct {
+	From, To string // paths on the --- and +++ lines; "/dev/null" for none
+	Hunks    []Hunk
+}
+
+// Hunk is one @@ section of a FilePatch
+type Hunk struct {
+	Line  int // where it starts in the original, from 1
+	Edits []Edit
+}
+
+// DevNull is the path a patch gives for a file that's created or deleted
+const DevNull = "/dev/null"
+
+// ParsePatch reads a unified diff, as diff -u or git diff write them. Lines
+// outside the file headers and hunks, like git's, are skipped.
+func ParsePatch(data []byte) ([]FilePatch, error) {
+	lines := Split(data)
+	var patches []FilePatch
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") {
+			continue
+		}
+		if i+1 == len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			return nil, fmt.Errorf("line %d: --- without +++", i+1)
+		}
+		p := FilePatch{From: patchPath(lines[i]), To: patchPath(lines[i+1])}
+		i += 2
+
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@ ") {
+			var from, fromCount, to, toCount int
+			if _, err := fmt.Sscanf(hunkHeader(lines[i]), "-%d,%d +%d,%d", &from, &fromCount, &to, &toCount); err != nil {
+				return nil, fmt.Errorf("line %d: bad hunk header: %s", i+1, strings.TrimSpace(lines[i]))
+			}
+			h := Hunk{Line: from}
+			if fromCount == 0 {
+				// Insertions give the line they go after
+				h.Line++
+			}
+			i++
+			for fromCount > 0 || toCount > 0 || i < len(lines) && strings.HasPrefix(lines[i], "\\") {
+				if i == len(lines) {
+					return nil, fmt.Errorf("%s: hunk at line %d ends early", p.To, from)
+				}
+				line := lines[i]
+				i++
+				if strings.HasPrefix(line, "\\") {
+					// No newline at end of file
+					if n := len(h.Edits); n > 0 {
+						h.Edits[n-1].Line = strings.TrimSuffix(h.Edits[n-1].Line, "\n")
+					}
+					continue
+				}
+				if line == "\n" {
+					// Editors strip the space off empty context lines
+					line = " \n"
+				}
+				e := Edit{Line: line[1:]}
+				switch line[0] {
+				case ' ':
+					e.Op = Equal
+					fromCount--
+					toCount--
+				case '-':
+					e.Op = Delete
+					fromCount--
+				case '+':
+					e.Op = Insert
+					toCount--
+				default:
+					return nil, fmt.Errorf("%s: unexpected line in hunk at line %d: %s", p.To, from, strings.TrimSpace(line))
+				}
+				h.Edits = append(h.Edits, e)
+			}
+			p.Hunks = append(p.Hunks, h)
+		}
+		i--
+		patches = append(patches, p)
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no unified diff found")
+	}
+	return patches, nil
+}
+
+// patchPath reads the path off a --- or +++ line, dropping a timestamp and
+// git's a/ and b/ prefixes
+func patchPath(line string) string {
+	name, _, _ := strings.Cut(strings.TrimRight(line[4:], "\n"), "\t")
+	if name == DevNull {
+		return name
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		name = name[2:]
+	}
+	return name
+}
+
+// hunkHeader returns the ranges of an @@ line, with counts filled in where
+// they were left out for being 1
+func hunkHeader(line string) string {
+	ranges, _, _ := strings.Cut(strings.TrimPrefix(line, "@@ "), " @@")
+	from, to, _ := strings.Cut(ranges, " ")
+	if !strings.Contains(from, ",") {
+		from += ",1"
+	}
+	if !strings.Contains(to, ",") {
+		to += ",1"
+	}
+	return from + " " + to
+}
+
+// Apply applies the patch to a file. A hunk whose lines moved since the
+// diff was made is found wherever they are now; one whose lines changed
+// fails.
+func (p *FilePatch) Apply(data []byte) ([]byte, error) {
+	lines := Split(data)
+	offset := 0 // how far hunks so far moved the lines after them
+	next := 0   // hunks apply in order, without overlapping
+	for n, h := range p.Hunks {
+		var old, new []string
+		for _, e := range h.Edits {
+			if e.Op != Insert {
+				old = append(old, e.Line)
+			}
+			if e.Op != Delete {
+				new = append(new, e.Line)
+			}
+		}
+
+		want := h.Line - 1 + offset
+		at := -1
+		for d := 0; at < 0 && (want-d >= next || want+d+len(old) <= len(lines)); d++ {
+			switch {
+			case want+d >= next && matches(lines, want+d, old):
+				at = want + d
+			case d > 0 && want-d >= next && matches(lines, want-d, old):
+				at = want - d
+			}
+		}
+		if at < 0 {
+			return nil, fmt.Errorf("hunk %d (line %d) doesn't apply: the lines it changes aren't there", n+1, h.Line)
+		}
+
+		lines = append(lines[:at], append(new, lines[at+len(old):]...)...)
+		offset += at - (h.Line - 1 + offset) + len(new) - len(old)
+		next = at + len(new)
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+func matches(lines []string, at int, want []string) bool {
+	if at < 0 || at+len(want) > len(lines) {
+		return false
+	}
+	for i, line := range want {
+		if lines[at+i] != line {
+			return false
+		}
+	}
+	return true
+}
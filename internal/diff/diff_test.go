@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// text joins lines into a file, each ending in a newline
+func text(lines ...string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestMerge(t *testing.T) {
+	base := text("a", "b", "c", "d", "e")
+	tests := []struct {
+		name         string
+		mine, theirs string
+		want         string
+		conflicts    int
+	}{
+		{"unchanged", base, base, base, 0},
+		{"only mine", text("a", "B", "c", "d", "e"), base, text("a", "B", "c", "d", "e"), 0},
+		{"only theirs", base, text("a", "b", "c", "D", "e"), text("a", "b", "c", "D", "e"), 0},
+		{"both, apart", text("A", "b", "c", "d", "e"), text("a", "b", "c", "d", "E"), text("A", "b", "c", "d", "E"), 0},
+		{"same change", text("a", "X", "c", "d", "e"), text("a", "X", "c", "d", "e"), text("a", "X", "c", "d", "e"), 0},
+		{"mine deletes, theirs appends", text("a", "c", "d", "e"), text("a", "b", "c", "d", "e", "f"), text("a", "c", "d", "e", "f"), 0},
+		{
+			"same line",
+			text("a", "mine", "c", "d", "e"), text("a", "theirs", "c", "d", "e"),
+			text("a", "<<<<<<< local", "mine", "||||||| base", "b", "=======", "theirs", ">>>>>>> 2.0.0", "c", "d", "e"), 1,
+		},
+		{
+			"adjacent lines",
+			text("a", "B", "c", "d", "e"), text("a", "b", "C", "d", "e"),
+			text("a", "<<<<<<< local", "B", "c", "||||||| base", "b", "c", "=======", "b", "C", ">>>>>>> 2.0.0", "d", "e"), 1,
+		},
+		{
+			"delete against edit",
+			text("a", "c", "d", "e"), text("a", "bb", "c", "d", "e"),
+			text("a", "<<<<<<< local", "||||||| base", "b", "=======", "bb", ">>>>>>> 2.0.0", "c", "d", "e"), 1,
+		},
+		{
+			"two conflicts",
+			text("1", "b", "c", "d", "5"), text("one", "b", "c", "d", "five"),
+			text("<<<<<<< local", "1", "||||||| base", "a", "=======", "one", ">>>>>>> 2.0.0", "b", "c", "d",
+				"<<<<<<< local", "5", "||||||| base", "e", "=======", "five", ">>>>>>> 2.0.0"), 2,
+		},
+		{
+			"both append",
+			text("a", "b", "c", "d", "e", "mine"), text("a", "b", "c", "d", "e", "theirs"),
+			text("a", "b", "c", "d", "e", "<<<<<<< local", "mine", "||||||| base", "=======", "theirs", ">>>>>>> 2.0.0"), 1,
+		},
+		{
+			"missing final newline",
+			"a\nb\nc\nd\nE", text("A", "b", "c", "d", "e"),
+			"A\nb\nc\nd\nE", 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := Merge([]byte(base), []byte(tt.mine), []byte(tt.theirs), "local", "base", "2.0.0")
+			if string(got) != tt.want || n != tt.conflicts {
+				t.Fatalf("Merge gave %d conflicts:\n%s\nwant %d:\n%s", n, got, tt.conflicts, tt.want)
+			}
+			if HasConflicts(got) != (n > 0) {
+				t.Fatalf("HasConflicts = %v with %d conflicts", HasConflicts(got), n)
+			}
+		})
+	}
+}
+
+func TestConflict(t *testing.T) {
+	tests := []struct {
+		name         string
+		mine, theirs string
+		want         string
+		conflicts    int
+	}{
+		{"same", text("a", "b"), text("a", "b"), text("a", "b"), 0},
+		{"one place", text("a", "b", "c"), text("a", "B", "c"), text("a", "<<<<<<< local", "b", "=======", "B", ">>>>>>> upstream", "c"), 1},
+		{"only theirs", text("a"), text("a", "b"), text("a", "<<<<<<< local", "=======", "b", ">>>>>>> upstream"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := Conflict([]byte(tt.mine), []byte(tt.theirs), "local", "upstream")
+			if string(got) != tt.want || n != tt.conflicts {
+				t.Fatalf("Conflict gave %d conflicts:\n%s\nwant %d:\n%s", n, got, tt.conflicts, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchRoundTrip(t *testing.T) {
+	a := text("a", "b", "c", "d", "e", "f", "g", "h", "i", "j")
+	b := text("a", "B", "c", "d", "e", "f", "g", "h", "i", "j", "k")
+	patches, err := ParsePatch([]byte(Unified("a/x.md", "b/x.md", []byte(a), []byte(b))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patches) != 1 {
+		t.Fatalf("ParsePatch gave %d patches, want 1", len(patches))
+	}
+	got, err := patches[0].Apply([]byte(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != b {
+		t.Fatalf("Apply gave:\n%s\nwant:\n%s", got, b)
+	}
+}
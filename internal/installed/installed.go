@@ -0,0 +1,368 @@
+// Package installed records what packs put on disk. Every agent directory
+// packs installs into keeps an index, .packs/<name>.json, with each pack's
+// source and the files it wrote there, or the managed blocks it wrote into
+// shared files.
+//
+// The files as upstream shipped them, to tell local edits apart and merge
+// them, and backups of what installs replaced live in ~/.packs instead,
+// under the agent directory's absolute path. In the agent directory,
+// agents would load them as rules, and project installs would commit them.
+package installed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tunajam/packs/internal/managed"
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// IndexDir holds the metadata of every pack in an agent directory
+const IndexDir = ".packs"
+
+// Meta describes an installed pack
+type Meta struct {
+	Name        string    `json:"name"`
+	Pack        string    `json:"pack,omitempty"` // Name at the source, when installed under another
+	Version     string    `json:"version,omitempty"`
+	Spec        string    `json:"spec,omitempty"` // Constraint or source ref updates stay within
+	Type        string    `json:"type,omitempty"`
+	Source      string    `json:"source,omitempty"`   // "registry", "github", "git", "local" or "archive"
+	Ref         string    `json:"ref,omitempty"`      // Canonical reference it came from, without a rev
+	Registry    string    `json:"registry,omitempty"` // Registry that served it
+	Commit      string    `json:"commit,omitempty"`   // GitHub commit SHA
+	ContentHash string    `json:"content_hash"`       // digest of the pack's files, verified at install
+	Agent       string    `json:"agent,omitempty"`    // Agent target it was installed for
+	Scope       string    `json:"scope,omitempty"`    // "project" or "user"; empty for --output
+	InstalledAt time.Time `json:"installed_at"`
+
+	// Outputs are the files packs wrote, relative to the agent directory,
+	// with the SHA-256 they were written with. Targets may reshape a pack,
+	// so these aren't necessarily the pack's own files.
+	Outputs []pack.File `json:"outputs,omitempty"`
+
+	// Store is the store entry Outputs are linked to, and Link how:
+	// "hardlink", "symlink", or "copy" for plain files
+	Store string `json:"store,omitempty"`
+	Link  string `json:"link,omitempty"`
+
+	// Overlays are the project's overlay files applied on top of the pack,
+	// relative to its overlay directory, with their SHA-256
+	Overlays []pack.File `json:"overlays,omitempty"`
+
+	// Managed is set when Outputs are managed blocks in shared files like
+	// AGENTS.md, hashed by their content, rather than whole files
+	Managed bool `json:"managed,omitempty"`
+}
+
+// Pack is an installed pack found on disk
+type Pack struct {
+	Meta
+	Root     string `json:"root"`     // agent directory
+	Dir      string `json:"dir"`      // pack directory, or its file for single-file layouts
+	Modified bool   `json:"modified"` // files changed since install
+}
+
+// MetaPath is where a pack's metadata lives in an agent directory
+func MetaPath(root, name string) string {
+	return filepath.Join(root, IndexDir, name+".json")
+}
+
+// StateDir holds the upstream copies and backups of every agent directory
+func StateDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".packs")
+}
+
+// stateFor is where one kind of state for an agent directory lives: its
+// absolute path, under a directory of StateDir
+func stateFor(kind, root string) string {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	return filepath.Join(StateDir(), kind, strings.TrimPrefix(abs, filepath.VolumeName(abs)))
+}
+
+// BasePath is where the upstream copy of a pack's files in an agent
+// directory lives. Files keep their output paths; managed blocks are stored
+// as their body under the path of the file holding them.
+func BasePath(root, name string) string {
+	return filepath.Join(stateFor("base", root), name)
+}
+
+// BackupPath is a new directory to keep a copy of a pack's files in before
+// an install replaces them, named by the time. A backup made within the
+// same second gets -2, -3 and so on, so it never replaces another.
+func BackupPath(root, name string) string {
+	base := filepath.Join(stateFor("backups", root), name+"-"+time.Now().Format("20060102-150405"))
+	dir := base
+	for i := 2; dirExists(dir); i++ {
+		dir = base + "-" + strconv.Itoa(i)
+	}
+	return dir
+}
+
+// Read loads the metadata of a pack installed in an agent directory. A pack
+// packs didn't install returns os.ErrNotExist.
+func Read(root, name string) (*Pack, error) {
+	m, err := readMeta(MetaPath(root, name))
+	if err != nil {
+		return nil, err
+	}
+	return newPack(root, m), nil
+}
+
+func readMeta(path string) (*Meta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Meta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Write stores the metadata of a pack installed in an agent directory
+func Write(root string, m *Meta) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := MetaPath(root, m.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Delete removes a pack's metadata and upstream copy, leaving its files
+func Delete(root, name string) error {
+	err := os.Remove(MetaPath(root, name))
+	base := BasePath(root, name)
+	os.RemoveAll(base)
+	prune(filepath.Dir(base), filepath.Join(StateDir(), "base"))
+	os.Remove(filepath.Join(root, IndexDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// prune removes dir and then its parents while they're empty, up to stop
+func prune(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop+string(filepath.Separator)) && os.Remove(dir) == nil {
+		dir = filepath.Dir(dir)
+	}
+}
+
+// List finds the packs installed in an agent directory, sorted by name.
+// Only packs recorded in the index count: files and managed blocks packs
+// didn't install are skipped, and a missing directory has no packs.
+func List(root string) ([]*Pack, error) {
+	byName := map[string]*Pack{}
+
+	entries, err := os.ReadDir(filepath.Join(root, IndexDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if m, err := readMeta(filepath.Join(root, IndexDir, e.Name())); err == nil && m.Name != "" {
+			byName[m.Name] = newPack(root, m)
+		}
+	}
+
+	packs := make([]*Pack, 0, len(byName))
+	for _, p := range byName {
+		packs = append(packs, p)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+func newPack(root string, m *Meta) *Pack {
+	p := &Pack{Meta: *m, Root: root}
+	p.Dir = filepath.Join(root, filepath.FromSlash(p.mainPath()))
+	p.Modified, _ = p.modified()
+	return p
+}
+
+// mainPath is the top-level file a pack installed as, or else its
+// directory
+func (p *Pack) mainPath() string {
+	for _, f := range p.Outputs {
+		if !strings.Contains(f.Path, "/") {
+			return f.Path
+		}
+	}
+	return p.Name
+}
+
+// Paths lists the files packs wrote, relative to the agent directory
+func (p *Pack) Paths() []string {
+	var paths []string
+	for _, f := range p.Outputs {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// Dirs lists the directories the pack owns in the agent directory, like
+// <name>/ for skill directories or the supporting files of a rule file
+func (p *Pack) Dirs() []string {
+	if p.Managed {
+		return nil
+	}
+	seen := map[string]bool{}
+	var dirs []string
+	for _, f := range p.Outputs {
+		top, _, ok := strings.Cut(f.Path, "/")
+		if ok && !seen[top] {
+			seen[top] = true
+			dirs = append(dirs, top)
+		}
+	}
+	return dirs
+}
+
+// ReadFiles reads what is on disk now at the paths the pack installed, for
+// comparing against a new version. Missing files are left out.
+func (p *Pack) ReadFiles() ([]pack.File, error) {
+	var files []pack.File
+	for _, out := range p.Outputs {
+		path := filepath.Join(p.Root, filepath.FromSlash(out.Path))
+		if p.Managed {
+			if b := p.block(out.Path); b != nil {
+				files = append(files, pack.File{Path: out.Path, Mode: 0644, Content: b.Body})
+			}
+			continue
+		}
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, pack.File{Path: out.Path, Mode: pack.NormalizeMode(uint32(info.Mode().Perm())), Content: content})
+	}
+	return files, nil
+}
+
+// ReadBase reads the upstream copy of the files the pack installed. Packs
+// installed before copies were kept return os.ErrNotExist.
+func (p *Pack) ReadBase() ([]pack.File, error) {
+	dir := BasePath(p.Root, p.Name)
+	if !dirExists(dir) {
+		return nil, os.ErrNotExist
+	}
+
+	var files []pack.File
+	for _, out := range p.Outputs {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(out.Path)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, pack.File{Path: out.Path, Mode: out.Mode, Content: content})
+	}
+	return files, nil
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// modified reports whether any installed file changed or went missing
+func (p *Pack) modified() (bool, error) {
+	for _, out := range p.Outputs {
+		if p.Managed {
+			if b := p.block(out.Path); b == nil || Hash(b.Body) != out.SHA256 {
+				return true, nil
+			}
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(p.Root, filepath.FromSlash(out.Path)))
+		if err != nil {
+			return true, nil
+		}
+		if Hash(data) != out.SHA256 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// block reads the pack's managed block in a file, or nil when it's gone
+func (p *Pack) block(rel string) *managed.Block {
+	data, err := os.ReadFile(filepath.Join(p.Root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil
+	}
+	b, _ := managed.Find(data, p.Name)
+	return b
+}
+
+// Foreign lists the files in a pack's directories that packs didn't write,
+// relative to the agent directory and sorted. Every file counts, dotfiles,
+// links and nested packs included, since removing the directories deletes
+// them all. Managed blocks have none.
+func Foreign(p *Pack) ([]string, error) {
+	var foreign []string
+	ours := map[string]bool{}
+	for _, f := range p.Outputs {
+		ours[f.Path] = true
+	}
+	for _, dir := range p.Dirs() {
+		top := filepath.Join(p.Root, filepath.FromSlash(dir))
+		err := filepath.WalkDir(top, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(p.Root, file)
+			if err != nil {
+				return err
+			}
+			if rel = filepath.ToSlash(rel); !ours[rel] {
+				foreign = append(foreign, rel)
+			}
+			return nil
+		})
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(foreign)
+	return foreign, nil
+}
+
+// Hash is the hex SHA-256 recorded for each output
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
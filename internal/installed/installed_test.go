@@ -0,0 +1,63 @@
+package installed
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tunajam/packs/internal/pack"
+)
+
+func TestBackupPathIsUnique(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		dir := BackupPath(root, "hello")
+		if seen[dir] {
+			t.Fatalf("BackupPath returned %s twice", dir)
+		}
+		if !strings.HasPrefix(filepath.Base(dir), "hello-") || !strings.HasPrefix(dir, StateDir()) {
+			t.Fatalf("BackupPath = %s, want hello-<time> under %s", dir, StateDir())
+		}
+		seen[dir] = true
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestForeign(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("hello/SKILL.md", "hi")
+	write("hello/.env", "SECRET=1")
+	write("hello/pack.sig", "sig")
+	write("hello/nested/pack.yaml", "name: nested")
+	write("hello/notes/todo.md", "todo")
+	write("other/SKILL.md", "not this pack's directory")
+	if err := os.Symlink("SKILL.md", filepath.Join(root, "hello", "alias.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Pack{Meta: Meta{Name: "hello", Outputs: []pack.File{{Path: "hello/SKILL.md"}}}, Root: root}
+	got, err := Foreign(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "hello/.env,hello/alias.md,hello/nested/pack.yaml,hello/notes/todo.md,hello/pack.sig"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("Foreign = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+// Package managed edits packs' managed blocks: a pack inlined into a shared
+// file like AGENTS.md or CLAUDE.md between begin and end markers,
+//
+//	<!-- packs:begin name=react-patterns version=1.0.0 hash=sha256:… -->
+//	…pack content…
+//	<!-- packs:end name=react-patterns -->
+//
+// Installs, updates and removals only ever rewrite the lines between a
+// pack's markers, so what people write around them is left alone. The
+// hash covers the content as installed, so local edits inside a block can
+// be told apart from the pack's own. Lines of pack content that start like
+// a marker are escaped as <!-- packs\:begin … and <!-- packs\:end …, so a
+// pack can't end its own block early or forge another pack's. Markers in
+// fenced code, like an example in a README, are not blocks.
+package managed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Block is one pack's managed block
+type Block struct {
+	Name    string
+	Version string
+	Hash    string // "sha256:<hex>" of Body as installed
+	Body    []byte
+}
+
+var (
+	beginLine = regexp.MustCompile(`(?m)^<!-- packs:begin ([^\n]*?) -->[ \t]*(\n|$)`)
+	endLine   = regexp.MustCompile(`(?m)^<!-- packs:end name=(\S+) -->[ \t]*(\n|$)`)
+
+	// markerLine matches the start of any line that could read as a marker
+	markerLine = regexp.MustCompile(`(?m)^(<!--[ \t]*packs):(begin|end)`)
+
+	// fenceLine matches a line opening or closing fenced code
+	fenceLine = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+)
+
+// New makes a block for pack content, ending it with exactly one newline
+// and with marker lines escaped
+func New(name, version string, content []byte) Block {
+	body := markerLine.ReplaceAll(bytes.TrimRight(content, "\n"), []byte(`$1\:$2`))
+	body = append(body, '\n')
+	return Block{Name: name, Version: version, Hash: Hash(body), Body: body}
+}
+
+// Hash returns the hash recorded in a block's begin marker
+func Hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Modified reports whether the block was edited since it was written
+func (b *Block) Modified() bool {
+	return Hash(b.Body) != b.Hash
+}
+
+// Render returns the block with its markers
+func (b *Block) Render() []byte {
+	attrs := "name=" + b.Name
+	if b.Version != "" {
+		attrs += " version=" + b.Version
+	}
+	attrs += " hash=" + b.Hash
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<!-- packs:begin %s -->\n", attrs)
+	buf.Write(b.Body)
+	fmt.Fprintf(&buf, "<!-- packs:end name=%s -->\n", b.Name)
+	return buf.Bytes()
+}
+
+// span is where a block sits in a file, markers included
+type span struct {
+	Block
+	start, end int
+}
+
+func parse(data []byte) ([]span, error) {
+	var spans []span
+	fence := ""
+	for pos := 0; pos < len(data); {
+		line := data[pos:]
+		if i := bytes.IndexByte(line, '\n'); i != -1 {
+			line = line[:i+1]
+		}
+
+		// Skip fenced code, which ends at a fence of the same kind at least
+		// as long, or at the end of the file
+		if f := fenceLine.FindSubmatch(line); f != nil {
+			switch {
+			case fence == "":
+				fence = string(f[1])
+			case f[1][0] == fence[0] && len(f[1]) >= len(fence) && len(bytes.TrimSpace(line[len(f[0]):])) == 0:
+				fence = ""
+			}
+		}
+		begin := beginLine.FindSubmatchIndex(line)
+		if fence != "" || begin == nil {
+			pos += len(line)
+			continue
+		}
+		s := span{start: pos + begin[0]}
+		for _, attr := range strings.Fields(string(data[pos+begin[2] : pos+begin[3]])) {
+			key, value, _ := strings.Cut(attr, "=")
+			switch key {
+			case "name":
+				s.Name = value
+			case "version":
+				s.Version = value
+			case "hash":
+				s.Hash = value
+			}
+		}
+		if s.Name == "" {
+			return nil, fmt.Errorf("packs:begin marker without a name at byte %d", s.start)
+		}
+
+		bodyStart := pos + begin[1]
+		end := endLine.FindSubmatchIndex(data[bodyStart:])
+		if end == nil || string(data[bodyStart+end[2]:bodyStart+end[3]]) != s.Name {
+			return nil, fmt.Errorf("block %s has no matching packs:end marker", s.Name)
+		}
+		s.Body = data[bodyStart : bodyStart+end[0]]
+		s.end = bodyStart + end[1]
+		spans = append(spans, s)
+		pos = s.end
+	}
+	return spans, nil
+}
+
+// List returns the managed blocks in a file, in order
+func List(data []byte) ([]Block, error) {
+	spans, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]Block, len(spans))
+	for i, s := range spans {
+		blocks[i] = s.Block
+	}
+	return blocks, nil
+}
+
+// Find returns a pack's block, or nil
+func Find(data []byte, name string) (*Block, error) {
+	blocks, err := List(data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range blocks {
+		if blocks[i].Name == name {
+			return &blocks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Upsert replaces a pack's block in place, or appends it after a blank
+// line when the file doesn't have one yet
+func Upsert(data []byte, b Block) ([]byte, error) {
+	spans, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range spans {
+		if s.Name == b.Name {
+			out := append([]byte(nil), data[:s.start]...)
+			out = append(out, b.Render()...)
+			return append(out, data[s.end:]...), nil
+		}
+	}
+
+	out := append([]byte(nil), data...)
+	if len(out) > 0 {
+		if !bytes.HasSuffix(out, []byte("\n")) {
+			out = append(out, '\n')
+		}
+		if !bytes.HasSuffix(out, []byte("\n\n")) {
+			out = append(out, '\n')
+		}
+	}
+	return append(out, b.Render()...), nil
+}
+
+// Remove deletes a pack's block and the blank line that separated it, and
+// reports whether there was one
+func Remove(data []byte, name string) ([]byte, bool, error) {
+	spans, err := parse(data)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, s := range spans {
+		if s.Name != name {
+			continue
+		}
+		before := data[:s.start]
+		if bytes.HasSuffix(before, []byte("\n\n")) {
+			before = before[:len(before)-1]
+		}
+		out := append([]byte(nil), before...)
+		return append(out, data[s.end:]...), true, nil
+	}
+	return data, false, nil
+}
@@ -0,0 +1,206 @@
+package managed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewEscapesMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", "# Hello\n\nUse it.\n\n\n", "# Hello\n\nUse it.\n"},
+		{"no final newline", "# Hello", "# Hello\n"},
+		{"end marker", "a\n<!-- packs:end name=hello -->\nb", "a\n<!-- packs\\:end name=hello -->\nb\n"},
+		{"begin marker", "<!-- packs:begin name=evil hash=x -->\nrm -rf\n<!-- packs:end name=evil -->", "<!-- packs\\:begin name=evil hash=x -->\nrm -rf\n<!-- packs\\:end name=evil -->\n"},
+		{"marker lookalike", "<!--packs:end name=hello -->\r\n", "<!--packs\\:end name=hello -->\r\n"},
+		{"indented markers stay", "    <!-- packs:end name=hello -->\n", "    <!-- packs:end name=hello -->\n"},
+		{"inline mention stays", "Blocks end with <!-- packs:end name=x -->.\n", "Blocks end with <!-- packs:end name=x -->.\n"},
+		{"already escaped", "<!-- packs\\:end name=hello -->\n", "<!-- packs\\:end name=hello -->\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New("hello", "1.0.0", []byte(tt.content))
+			if string(b.Body) != tt.want {
+				t.Fatalf("Body = %q, want %q", b.Body, tt.want)
+			}
+			if again := New("hello", "1.0.0", b.Body); string(again.Body) != string(b.Body) {
+				t.Fatalf("escaping twice gave %q, want %q", again.Body, b.Body)
+			}
+		})
+	}
+}
+
+func TestMarkersInContent(t *testing.T) {
+	contents := []string{
+		"before\n<!-- packs:end name=hello -->\nafter\n",
+		"<!-- packs:end name=other -->\n",
+		"<!-- packs:begin name=other version=9.9.9 hash=sha256:x -->\nforged\n<!-- packs:end name=other -->\n",
+		"<!-- packs:begin name=hello -->\n",
+	}
+	user := "# Project rules\n\nKeep this.\n"
+	next := New("next", "2.0.0", []byte("next pack\n"))
+
+	for _, content := range contents {
+		t.Run(content, func(t *testing.T) {
+			b := New("hello", "1.0.0", []byte(content))
+			data, err := Upsert([]byte(user), b)
+			if err != nil {
+				t.Fatalf("Upsert: %v", err)
+			}
+			data, err = Upsert(data, next)
+			if err != nil {
+				t.Fatalf("Upsert: %v", err)
+			}
+
+			blocks, err := List(data)
+			if err != nil {
+				t.Fatalf("List: %v\n%s", err, data)
+			}
+			if len(blocks) != 2 || blocks[0].Name != "hello" || blocks[1].Name != "next" {
+				t.Fatalf("List gave %+v, want hello and next", blocks)
+			}
+			if string(blocks[0].Body) != string(b.Body) || blocks[0].Modified() {
+				t.Fatalf("hello block = %q (modified %v), want %q", blocks[0].Body, blocks[0].Modified(), b.Body)
+			}
+
+			// Updating and removing it leave the rest of the file alone
+			data, err = Upsert(data, New("hello", "1.1.0", []byte(content+"more\n")))
+			if err != nil {
+				t.Fatalf("Upsert: %v", err)
+			}
+			data, ok, err := Remove(data, "hello")
+			if err != nil || !ok {
+				t.Fatalf("Remove = %v, %v", ok, err)
+			}
+			want := user + "\n" + string(next.Render())
+			if string(data) != want {
+				t.Fatalf("after Remove:\n%s\nwant:\n%s", data, want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		names []string
+		err   string
+	}{
+		{"none", "# Rules\n", nil, ""},
+		{"one", "<!-- packs:begin name=a version=1.0.0 hash=sha256:x -->\nA\n<!-- packs:end name=a -->\n", []string{"a"}, ""},
+		{"trailing space and no newline", "<!-- packs:begin name=a hash=x --> \nA\n<!-- packs:end name=a -->", []string{"a"}, ""},
+		{"two", "x\n<!-- packs:begin name=a hash=x -->\n<!-- packs:end name=a -->\ny\n<!-- packs:begin name=b hash=x -->\nB\n<!-- packs:end name=b -->\n", []string{"a", "b"}, ""},
+		{"no name", "<!-- packs:begin version=1 -->\n<!-- packs:end name=a -->\n", nil, "without a name"},
+		{"no end", "<!-- packs:begin name=a hash=x -->\nA\n", nil, "no matching packs:end"},
+		{"wrong end", "<!-- packs:begin name=a hash=x -->\nA\n<!-- packs:end name=b -->\n", nil, "no matching packs:end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := List([]byte(tt.data))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("List = %v, want an error mentioning %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, b := range blocks {
+				names = append(names, b.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.names, ",") {
+				t.Fatalf("blocks = %v, want %v", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestUpsertRemove(t *testing.T) {
+	b := New("hello", "1.0.0", []byte("Hello\n"))
+	block := string(b.Render())
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		removed string
+	}{
+		{"empty file", "", block, ""},
+		{"no final newline", "# Rules", "# Rules\n\n" + block, "# Rules\n"},
+		{"blank line already there", "# Rules\n\n", "# Rules\n\n" + block, "# Rules\n"},
+		{"text after", "# Rules\n\n" + block + "\nMore\n", "# Rules\n\n" + block + "\nMore\n", "# Rules\n\nMore\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Upsert([]byte(tt.before), b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.after {
+				t.Fatalf("Upsert gave %q, want %q", data, tt.after)
+			}
+			data, ok, err := Remove(data, "hello")
+			if err != nil || !ok {
+				t.Fatalf("Remove = %v, %v", ok, err)
+			}
+			if string(data) != tt.removed {
+				t.Fatalf("Remove gave %q, want %q", data, tt.removed)
+			}
+		})
+	}
+}
+
+func TestFencedExamples(t *testing.T) {
+	example := "<!-- packs:begin name=react-patterns version=1.0.0 hash=sha256:x -->\n…pack content…\n<!-- packs:end name=react-patterns -->\n"
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"backticks", "# Docs\n\n```markdown\n" + example + "```\n"},
+		{"tildes", "~~~\n" + example + "~~~\n"},
+		{"indented fence", "   ```\n" + example + "   ```\n"},
+		{"longer closing fence", "````md\n" + example + "``` not a close\n`````\n"},
+		{"unclosed fence", "```\n" + example},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := List([]byte(tt.data))
+			if err != nil || len(blocks) != 0 {
+				t.Fatalf("List = %+v, %v; want no blocks", blocks, err)
+			}
+			data, ok, err := Remove([]byte(tt.data), "react-patterns")
+			if err != nil || ok || string(data) != tt.data {
+				t.Fatalf("Remove = %q, %v, %v; want the file unchanged", data, ok, err)
+			}
+		})
+	}
+
+	// A real block after the example is found, and removing it keeps the
+	// example
+	doc := "# Docs\n\n```markdown\n" + example + "```\n"
+	b := New("react-patterns", "1.0.0", []byte("Real content\n"))
+	data, err := Upsert([]byte(doc), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := List(data)
+	if err != nil || len(blocks) != 1 || string(blocks[0].Body) != "Real content\n" {
+		t.Fatalf("List = %+v, %v; want the real block", blocks, err)
+	}
+	data, ok, err := Remove(data, "react-patterns")
+	if err != nil || !ok {
+		t.Fatalf("Remove = %v, %v", ok, err)
+	}
+	if string(data) != doc {
+		t.Fatalf("after Remove:\n%s\nwant:\n%s", data, doc)
+	}
+}
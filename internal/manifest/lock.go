@@ -0,0 +1,80 @@
+// Package manifest reads and writes a project's pack files.
+package manifest
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFile is the lockfile name, next to the manifest
+const LockFile = "packs.lock"
+
+// LockVersion is the current lockfile format version
+const LockVersion = 1
+
+// Lock is the contents of packs.lock: the exact set of packs to install
+type Lock struct {
+	Version int          `yaml:"version"`
+	Packs   []LockedPack `yaml:"packs"`
+}
+
+// LockedPack is one resolved pack
+type LockedPack struct {
+	Name        string `yaml:"name"`
+	Spec        string `yaml:"spec,omitempty"` // Manifest entry it was resolved from
+	Version     string `yaml:"version,omitempty"`
+	Source      string `yaml:"source"`             // "registry", "github", "git", "local" or "archive"
+	Ref         string `yaml:"ref,omitempty"`      // Canonical reference it came from, without a rev
+	Registry    string `yaml:"registry,omitempty"` // Registry that served it
+	ContentHash string `yaml:"content_hash"`       // sha256:<hex>
+	Commit      string `yaml:"commit,omitempty"`   // GitHub or git commit SHA
+}
+
+// ReadLock reads a lockfile. A missing file returns os.ErrNotExist.
+func ReadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if lock.Version > LockVersion {
+		return nil, fmt.Errorf("%s was written by a newer packs (format %d); upgrade packs", path, lock.Version)
+	}
+	return &lock, nil
+}
+
+// Put adds or replaces the entry for p.Name
+func (l *Lock) Put(p LockedPack) {
+	if existing := l.Find(p.Name); existing != nil {
+		*existing = p
+		return
+	}
+	l.Packs = append(l.Packs, p)
+}
+
+// Remove drops a pack from the lock and reports whether it was there
+func (l *Lock) Remove(name string) bool {
+	for i := range l.Packs {
+		if l.Packs[i].Name == name {
+			l.Packs = append(l.Packs[:i], l.Packs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the locked entry for a pack name, or nil
+func (l *Lock) Find(name string) *LockedPack {
+	for i := range l.Packs {
+		if l.Packs[i].Name == name {
+			return &l.Packs[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tunajam/packs/internal/packref"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the project manifest name
+const ManifestFile = "packs.yaml"
+
+// Manifest is the contents of packs.yaml: the packs a project wants, keyed by
+// install name, each with a version constraint or a pack reference.
+//
+//	packs:
+//	  commit-message: ^1.0
+//	  docx: gh:anthropics/skills/docx@main
+//	  house-style: https://example.com/house-style.tgz
+//	  team-rules: git+ssh://git@example.com/team/packs.git//rules#main
+//	  drafts: ./packs/drafts
+type Manifest struct {
+	Packs map[string]string `yaml:"packs"`
+}
+
+// Dependency is one manifest entry
+type Dependency struct {
+	Name string
+	Spec string // version constraint, "latest", or a pack reference like gh:user/repo/path[@ref] or ./dir
+}
+
+// Ref is what the dependency points to: the registry pack with its name
+// within the version constraint, or the reference it gives
+func (d Dependency) Ref() packref.PackRef {
+	return packref.ParseSpec(d.Name, d.Spec)
+}
+
+// ReadManifest reads a manifest. A missing file returns os.ErrNotExist.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Dependencies returns the manifest entries sorted by name
+func (m *Manifest) Dependencies() []Dependency {
+	deps := make([]Dependency, 0, len(m.Packs))
+	for name, spec := range m.Packs {
+		deps = append(deps, Dependency{Name: name, Spec: strings.TrimSpace(spec)})
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+// AddDependency sets a pack in a manifest file, creating the file if needed.
+// Comments and the order of other entries are kept.
+func AddDependency(path, name, spec string) error {
+	doc, err := readDoc(path)
+	if err != nil {
+		return err
+	}
+
+	root := doc.Content[0]
+	packs := mappingValue(root, "packs")
+	if packs == nil || packs.Kind != yaml.MappingNode {
+		packs = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(root, "packs", packs)
+	}
+	setMappingValue(packs, name, &yaml.Node{Kind: yaml.ScalarNode, Value: spec})
+
+	return writeDoc(path, doc)
+}
+
+// RemoveDependency deletes a pack from a manifest file, keeping comments
+// and the other entries. It reports whether the pack was listed.
+func RemoveDependency(path, name string) (bool, error) {
+	doc, err := readDoc(path)
+	if err != nil {
+		return false, err
+	}
+
+	packs := mappingValue(doc.Content[0], "packs")
+	if packs == nil || !deleteMappingValue(packs, name) {
+		return false, nil
+	}
+	return true, writeDoc(path, doc)
+}
+
+// WriteLock writes a lockfile with packs sorted by name, so lockfiles diff
+// cleanly
+func WriteLock(path string, lock *Lock) error {
+	lock.Version = LockVersion
+	sort.Slice(lock.Packs, func(i, j int) bool { return lock.Packs[i].Name < lock.Packs[j].Name })
+
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by packs. Do not edit; run 'packs install' to update.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(lock); err != nil {
+		return err
+	}
+	enc.Close()
+
+	return writeFile(path, buf.Bytes())
+}
+
+func readDoc(path string) (*yaml.Node, error) {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid %s: expected a mapping", path)
+	}
+	return &doc, nil
+}
+
+func writeDoc(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	enc.Close()
+	return writeFile(path, buf.Bytes())
+}
+
+func writeFile(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func deleteMappingValue(node *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		value,
+	)
+}
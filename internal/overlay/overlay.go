@@ -0,0 +1,366 @@
+// Package overlay applies a project's own changes to the packs it installs,
+// so upstream updates flow in and the project's additions are reapplied
+// on top. A project keeps them in .packs/overlays/<pack>/, one file per
+// change, named after the pack file it changes:
+//
+//	SKILL.prepend.md   added at the start of SKILL.md, after its frontmatter
+//	SKILL.append.md    added at the end of SKILL.md
+//	SKILL.replace.md   sections that replace the ones with the same heading
+//	fix.patch          a unified diff against the pack's files
+//
+// Files in subdirectories change the pack files at the same path, like
+// references/api.append.md. Patches apply first, then section replacements,
+// prepends and appends.
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tunajam/packs/internal/diff"
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// Dir is where a project keeps overlays, relative to its root
+var Dir = filepath.Join(".packs", "overlays")
+
+// Kind is how an overlay file changes a pack file
+type Kind string
+
+// Kinds, in the order they apply
+const (
+	Patch   Kind = "patch"
+	Replace Kind = "replace"
+	Prepend Kind = "prepend"
+	Append  Kind = "append"
+)
+
+var kinds = []Kind{Patch, Replace, Prepend, Append}
+
+// Overlay is one file of a pack's overlay
+type Overlay struct {
+	Path    string // relative to the pack's overlay directory
+	Kind    Kind
+	Target  string // the pack file it changes; patches name their own
+	Content []byte
+}
+
+// Path returns a pack's overlay directory in a project
+func Path(root, name string) string {
+	return filepath.Join(root, Dir, name)
+}
+
+// Load reads a pack's overlays in a project, in the order they apply. A
+// pack without an overlay directory has none.
+func Load(root, name string) ([]Overlay, error) {
+	dir := Path(root, name)
+	files, err := pack.ReadFiles(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var overlays []Overlay
+	for _, f := range files {
+		kind, target, ok := parseName(f.Path)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an overlay\nName overlay files after the file they change, like SKILL.append.md, SKILL.prepend.md or SKILL.replace.md, or end patches in .patch", filepath.Join(dir, filepath.FromSlash(f.Path)))
+		}
+		overlays = append(overlays, Overlay{Path: f.Path, Kind: kind, Target: target, Content: f.Content})
+	}
+	sort.SliceStable(overlays, func(i, j int) bool {
+		return order(overlays[i].Kind) < order(overlays[j].Kind)
+	})
+	return overlays, nil
+}
+
+func order(k Kind) int {
+	for i, kind := range kinds {
+		if kind == k {
+			return i
+		}
+	}
+	return len(kinds)
+}
+
+// parseName reads the kind of an overlay file and the pack file it changes
+// from its name: SKILL.append.md changes SKILL.md, notes.append changes
+// notes
+func parseName(rel string) (Kind, string, bool) {
+	if strings.HasSuffix(rel, ".patch") {
+		return Patch, "", true
+	}
+	dir, base := path.Split(rel)
+	for _, k := range []Kind{Replace, Prepend, Append} {
+		marker := "." + string(k)
+		i := strings.LastIndex(base, marker)
+		if i <= 0 {
+			continue
+		}
+		ext := base[i+len(marker):]
+		if ext == "" || strings.HasPrefix(ext, ".") && !strings.Contains(ext[1:], ".") {
+			return k, dir + base[:i] + ext, true
+		}
+	}
+	return "", "", false
+}
+
+// Apply returns a pack's files with overlays applied, leaving files as
+// they are
+func Apply(files []pack.File, overlays []Overlay) ([]pack.File, error) {
+	out := append([]pack.File(nil), files...)
+	find := func(rel string) int {
+		for i := range out {
+			if out[i].Path == rel {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, o := range overlays {
+		if o.Kind == Patch {
+			patches, err := diff.ParsePatch(o.Content)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", o.Path, err)
+			}
+			for _, p := range patches {
+				if out, err = applyPatch(out, find, p); err != nil {
+					return nil, fmt.Errorf("%s: %w", o.Path, err)
+				}
+			}
+			continue
+		}
+
+		i := find(o.Target)
+		if i < 0 {
+			return nil, fmt.Errorf("%s: the pack has no %s", o.Path, o.Target)
+		}
+		var content []byte
+		switch o.Kind {
+		case Replace:
+			var err error
+			if content, err = replaceSections(out[i].Content, o.Content); err != nil {
+				return nil, fmt.Errorf("%s: %w", o.Path, err)
+			}
+		case Prepend:
+			content = prepend(out[i].Content, o.Content)
+		case Append:
+			content = appendTo(out[i].Content, o.Content)
+		}
+		out[i] = changed(out[i], content)
+	}
+	return out, nil
+}
+
+// applyPatch applies one file of a patch, which may also create or delete
+// the file
+func applyPatch(files []pack.File, find func(string) int, p diff.FilePatch) ([]pack.File, error) {
+	switch {
+	case p.From == diff.DevNull:
+		if !pack.ValidPath(p.To) {
+			return nil, fmt.Errorf("%w: %q", pack.ErrUnsafePath, p.To)
+		}
+		if find(p.To) >= 0 {
+			return nil, fmt.Errorf("%s already exists", p.To)
+		}
+		content, err := p.Apply(nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.To, err)
+		}
+		return append(files, pack.File{Path: p.To, Mode: 0644, Content: content}), nil
+	case p.To == diff.DevNull:
+		i := find(p.From)
+		if i < 0 {
+			return nil, fmt.Errorf("the pack has no %s to delete", p.From)
+		}
+		return append(files[:i:i], files[i+1:]...), nil
+	}
+
+	i := find(p.To)
+	if i < 0 {
+		return nil, fmt.Errorf("the pack has no %s", p.To)
+	}
+	content, err := p.Apply(files[i].Content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.To, err)
+	}
+	files[i] = changed(files[i], content)
+	return files, nil
+}
+
+// changed is f with new content, which no longer has the hash it declared
+func changed(f pack.File, content []byte) pack.File {
+	f.Content = content
+	f.SHA256 = ""
+	return f
+}
+
+// prepend adds text at the start of data, after its frontmatter if it has
+// any, with a blank line between
+func prepend(data, text []byte) []byte {
+	head, body := splitFrontmatter(data)
+	out := append([]byte(nil), head...)
+	if len(head) > 0 {
+		out = append(out, '\n')
+	}
+	out = append(out, trimNewlines(text)...)
+	if len(body) == 0 {
+		return append(out, '\n')
+	}
+	out = append(out, "\n\n"...)
+	return append(out, body...)
+}
+
+// appendTo adds text at the end of data, with a blank line between
+func appendTo(data, text []byte) []byte {
+	out := trimNewlines(data)
+	if len(out) > 0 {
+		out = append(out, "\n\n"...)
+	}
+	out = append(out, trimNewlines(text)...)
+	return append(out, '\n')
+}
+
+func trimNewlines(data []byte) []byte {
+	return []byte(strings.TrimRight(string(data), "\n"))
+}
+
+// splitFrontmatter splits YAML frontmatter between --- lines off the start
+// of a file
+func splitFrontmatter(data []byte) (head, body []byte) {
+	lines := diff.Split(data)
+	if len(lines) == 0 || strings.TrimRight(lines[0], "\r\n") != "---" {
+		return nil, data
+	}
+	n := len(lines[0])
+	for _, line := range lines[1:] {
+		n += len(line)
+		if strings.TrimRight(line, "\r\n") == "---" {
+			head = data[:n]
+			if !strings.HasSuffix(line, "\n") {
+				head = append(append([]byte(nil), head...), '\n')
+			}
+			return head, []byte(strings.TrimLeft(string(data[n:]), "\n"))
+		}
+	}
+	return nil, data
+}
+
+// heading matches a Markdown ATX heading
+var heading = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+
+// section is a heading and the lines under it, up to the next heading at
+// the same level or above
+type section struct {
+	level      int
+	title      string
+	start, end int // line indexes
+}
+
+// sections lists the sections of Markdown lines, outer ones before the
+// ones nested in them. Headings in frontmatter and code blocks don't count.
+func sections(lines []string) []section {
+	var headings []section
+	fence := ""
+	start := 0
+	if len(lines) > 0 && strings.TrimRight(lines[0], "\r\n") == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimRight(lines[i], "\r\n") == "---" {
+				start = i + 1
+				break
+			}
+		}
+	}
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if m := heading.FindStringSubmatch(line); m != nil {
+			headings = append(headings, section{level: len(m[1]), title: m[2], start: i})
+		}
+	}
+
+	for i := range headings {
+		headings[i].end = len(lines)
+		for _, next := range headings[i+1:] {
+			if next.level <= headings[i].level {
+				headings[i].end = next.start
+				break
+			}
+		}
+	}
+	return headings
+}
+
+// replaceSections replaces each section of data that has the same heading
+// as a top section of with, keeping the rest of data as it is
+func replaceSections(data, with []byte) ([]byte, error) {
+	lines := diff.Split(data)
+	repl := diff.Split(with)
+	secs := sections(repl)
+	if len(secs) == 0 {
+		return nil, fmt.Errorf("no headings; a replacement starts with the heading of the section it replaces")
+	}
+	if strings.TrimSpace(strings.Join(repl[:secs[0].start], "")) != "" {
+		return nil, fmt.Errorf("text before the first heading; a replacement starts with the heading of the section it replaces")
+	}
+
+	next := 0
+	for _, s := range secs {
+		if s.start < next {
+			// Nested in the last one, which brings it along
+			continue
+		}
+		next = s.end
+
+		var target *section
+		for _, t := range sections(lines) {
+			if t.level == s.level && t.title == s.title {
+				target = &t
+				break
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("no section %q to replace", strings.Repeat("#", s.level)+" "+s.title)
+		}
+
+		body := append([]string(nil), repl[s.start:s.end]...)
+		body = trimBlankLines(body)
+		if target.end < len(lines) {
+			// Keep a blank line before the next heading
+			body = append(body, "\n")
+		}
+		lines = append(lines[:target.start], append(body, lines[target.end:]...)...)
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// trimBlankLines drops blank lines at the end and makes sure the last line
+// ends
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		lines[n-1] += "\n"
+	}
+	return lines
+}
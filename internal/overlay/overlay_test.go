@@ -0,0 +1,249 @@
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tunajam/packs/internal/pack"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   Kind
+		target string
+		ok     bool
+	}{
+		{"SKILL.append.md", Append, "SKILL.md", true},
+		{"SKILL.prepend.md", Prepend, "SKILL.md", true},
+		{"SKILL.replace.md", Replace, "SKILL.md", true},
+		{"references/api.append.md", Append, "references/api.md", true},
+		{"notes.append", Append, "notes", true},
+		{"fix.patch", Patch, "", true},
+		{"sub/fix.patch", Patch, "", true},
+		{"my.append.notes.append.md", Append, "my.append.notes.md", true},
+		{"SKILL.md", "", "", false},
+		{".append.md", "", "", false},
+		{"SKILL.append.tar.gz", "", "", false},
+		{"SKILL.appendix.md", "", "", false},
+		{"README", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, target, ok := parseName(tt.name)
+			if kind != tt.kind || target != tt.target || ok != tt.ok {
+				t.Errorf("parseName(%q) = %q, %q, %v, want %q, %q, %v", tt.name, kind, target, ok, tt.kind, tt.target, tt.ok)
+			}
+		})
+	}
+}
+
+const skill = `---
+name: hello
+---
+
+# Hello
+
+Intro.
+
+## Usage
+
+Run it.
+
+` + "```sh" + `
+## Not a heading
+` + "```" + `
+
+## Notes
+
+Upstream notes.
+`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		overlays []Overlay
+		want     map[string]string // files that changed, by path; "" for deleted
+		err      string
+	}{
+		{
+			name:     "append",
+			overlays: []Overlay{{Path: "SKILL.append.md", Kind: Append, Target: "SKILL.md", Content: []byte("Our notes.\n\n")}},
+			want:     map[string]string{"SKILL.md": skill + "\nOur notes.\n"},
+		},
+		{
+			name:     "prepend after frontmatter",
+			overlays: []Overlay{{Path: "SKILL.prepend.md", Kind: Prepend, Target: "SKILL.md", Content: []byte("Read this first.\n")}},
+			want:     map[string]string{"SKILL.md": strings.Replace(skill, "---\n\n# Hello", "---\n\nRead this first.\n\n# Hello", 1)},
+		},
+		{
+			name:     "prepend without frontmatter",
+			overlays: []Overlay{{Path: "run.prepend.sh", Kind: Prepend, Target: "scripts/run.sh", Content: []byte("set -e\n")}},
+			want:     map[string]string{"scripts/run.sh": "set -e\n\necho hi\n"},
+		},
+		{
+			name:     "replace section",
+			overlays: []Overlay{{Path: "SKILL.replace.md", Kind: Replace, Target: "SKILL.md", Content: []byte("## Usage\n\nRun it our way.\n\n")}},
+			want: map[string]string{"SKILL.md": strings.Replace(skill,
+				"## Usage\n\nRun it.\n\n```sh\n## Not a heading\n```\n\n", "## Usage\n\nRun it our way.\n\n", 1)},
+		},
+		{
+			name:     "replace last section",
+			overlays: []Overlay{{Path: "SKILL.replace.md", Kind: Replace, Target: "SKILL.md", Content: []byte("## Notes\n\nOur notes.")}},
+			want:     map[string]string{"SKILL.md": strings.Replace(skill, "Upstream notes.\n", "Our notes.\n", 1)},
+		},
+		{
+			name:     "replace heading in code",
+			overlays: []Overlay{{Path: "SKILL.replace.md", Kind: Replace, Target: "SKILL.md", Content: []byte("## Not a heading\n\nx\n")}},
+			err:      `no section "## Not a heading" to replace`,
+		},
+		{
+			name:     "replace without heading",
+			overlays: []Overlay{{Path: "SKILL.replace.md", Kind: Replace, Target: "SKILL.md", Content: []byte("Intro.\n\n## Usage\n")}},
+			err:      "text before the first heading",
+		},
+		{
+			name:     "missing target",
+			overlays: []Overlay{{Path: "GUIDE.append.md", Kind: Append, Target: "GUIDE.md", Content: []byte("x\n")}},
+			err:      "the pack has no GUIDE.md",
+		},
+		{
+			name: "patch",
+			overlays: []Overlay{{Path: "fix.patch", Kind: Patch, Content: []byte(`--- a/scripts/run.sh
++++ b/scripts/run.sh
+@@ -1 +1 @@
+-echo hi
++echo hello
+--- /dev/null
++++ b/scripts/new.sh
+@@ -0,0 +1 @@
++echo new
+--- a/LICENSE
++++ /dev/null
+@@ -1 +0,0 @@
+-MIT
+`)}},
+			want: map[string]string{"scripts/run.sh": "echo hello\n", "scripts/new.sh": "echo new\n", "LICENSE": ""},
+		},
+		{
+			name: "patch that no longer applies",
+			overlays: []Overlay{{Path: "fix.patch", Kind: Patch, Content: []byte(`--- a/scripts/run.sh
++++ b/scripts/run.sh
+@@ -1 +1 @@
+-echo bye
++echo hello
+`)}},
+			err: "fix.patch: scripts/run.sh",
+		},
+		{
+			name: "patch outside the pack",
+			overlays: []Overlay{{Path: "fix.patch", Kind: Patch, Content: []byte(`--- /dev/null
++++ b/../escape.sh
+@@ -0,0 +1 @@
++echo out
+`)}},
+			err: "unsafe",
+		},
+		{
+			name: "patch then append",
+			overlays: []Overlay{
+				{Path: "fix.patch", Kind: Patch, Content: []byte("--- a/scripts/run.sh\n+++ b/scripts/run.sh\n@@ -1 +1 @@\n-echo hi\n+echo hello\n")},
+				{Path: "run.append.sh", Kind: Append, Target: "scripts/run.sh", Content: []byte("echo done\n")},
+			},
+			want: map[string]string{"scripts/run.sh": "echo hello\n\necho done\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := []pack.File{
+				{Path: "LICENSE", Mode: 0644, Content: []byte("MIT\n"), SHA256: "x"},
+				{Path: "SKILL.md", Mode: 0644, Content: []byte(skill), SHA256: "x"},
+				{Path: "scripts/run.sh", Mode: 0755, Content: []byte("echo hi\n"), SHA256: "x"},
+			}
+			got, err := Apply(files, tt.overlays)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Apply = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			byPath := map[string]pack.File{}
+			for _, f := range got {
+				byPath[f.Path] = f
+			}
+			for _, f := range files {
+				want, changed := tt.want[f.Path]
+				g, ok := byPath[f.Path]
+				switch {
+				case changed && want == "":
+					if ok {
+						t.Errorf("%s not deleted", f.Path)
+					}
+				case changed:
+					if string(g.Content) != want {
+						t.Errorf("%s =\n%s\nwant\n%s", f.Path, g.Content, want)
+					}
+					if g.SHA256 != "" || g.Mode != f.Mode {
+						t.Errorf("%s: SHA256 %q, mode %o after change", f.Path, g.SHA256, g.Mode)
+					}
+				case !ok || string(g.Content) != string(f.Content) || g.SHA256 != f.SHA256:
+					t.Errorf("%s changed", f.Path)
+				}
+			}
+			for path, want := range tt.want {
+				if g, ok := byPath[path]; want != "" && (!ok || string(g.Content) != want) {
+					t.Errorf("%s = %q, want %q", path, g.Content, want)
+				}
+			}
+
+			// The pack's own files are left as they were
+			if string(files[1].Content) != skill || string(files[2].Content) != "echo hi\n" || files[0].Path != "LICENSE" {
+				t.Error("Apply changed its input")
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	if overlays, err := Load(root, "hello"); err != nil || overlays != nil {
+		t.Fatalf("Load without overlays = %v, %v", overlays, err)
+	}
+
+	dir := Path(root, "hello")
+	for name, content := range map[string]string{
+		"SKILL.append.md":  "a",
+		"SKILL.prepend.md": "p",
+		"SKILL.replace.md": "r",
+		"z.patch":          "d",
+	} {
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+
+	overlays, err := Load(root, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []Kind
+	for _, o := range overlays {
+		kinds = append(kinds, o.Kind)
+	}
+	if want := []Kind{Patch, Replace, Prepend, Append}; !slices.Equal(kinds, want) {
+		t.Errorf("kinds in order %v, want %v", kinds, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("x"), 0644)
+	if _, err := Load(root, "hello"); err == nil || !strings.Contains(err.Error(), "notes.md is not an overlay") {
+		t.Errorf("Load with a stray file = %v", err)
+	}
+}
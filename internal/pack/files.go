@@ -0,0 +1,203 @@
+package pack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrUnsafePath is returned for file paths that would escape the pack
+	ErrUnsafePath = errors.New("unsafe file path in pack")
+
+	// ErrFileHash is returned when a file doesn't match its declared hash
+	ErrFileHash = errors.New("file hash mismatch")
+
+	// ErrInvalidName is returned for pack names ValidName rejects
+	ErrInvalidName = errors.New("invalid pack name")
+)
+
+// File is one file of a pack directory
+type File struct {
+	Path    string `json:"path"`             // slash-separated, relative to the pack root
+	Mode    uint32 `json:"mode"`             // permission bits, 0644 or 0755
+	SHA256  string `json:"sha256,omitempty"` // declared hex SHA-256, checked by Verify
+	Content []byte `json:"content,omitempty"`
+}
+
+// Excluded are files that belong next to a pack but not inside it: its
+// signature
+var Excluded = []string{"pack.sig"}
+
+// Included reports whether a relative path is part of a pack's file set.
+// Dotfiles and dot-directories (.git, .DS_Store) never are.
+func Included(rel string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	for _, name := range Excluded {
+		if rel == name {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidPath reports whether a pack file path is safe to write under a pack
+// directory: relative, clean and without "..".
+func ValidPath(p string) bool {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, "\\") {
+		return false
+	}
+	clean := path.Clean(p)
+	return clean == p && clean != "." && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// ValidName reports whether a pack name is safe to install under: one path
+// element that isn't hidden and has no spaces, so a pack's directory, its
+// metadata and its overlays stay where they belong
+func ValidName(name string) bool {
+	if name == "" || name == ".." || strings.HasPrefix(name, ".") {
+		return false
+	}
+	return !strings.ContainsFunc(name, func(r rune) bool {
+		return r == '/' || r == '\\' || r == ':' || unicode.IsSpace(r) || unicode.IsControl(r)
+	})
+}
+
+// NormalizeMode reduces a file mode to 0755 for executables and 0644
+// otherwise, so installs don't depend on the author's umask
+func NormalizeMode(mode uint32) uint32 {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// ReadFiles reads every file of a pack directory, sorted by path. Symlinks,
+// excluded files and nested packs (subdirectories with their own pack.yaml,
+// like version folders in a registry) are skipped.
+func ReadFiles(dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if !Included(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if _, err := os.Stat(filepath.Join(p, MetaFile)); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, File{
+			Path:    rel,
+			Mode:    NormalizeMode(uint32(info.Mode().Perm())),
+			Content: content,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	SortFiles(files)
+	return files, nil
+}
+
+// Nested reports whether rel lies in a nested pack, given the directories
+// of a file set that contain a pack.yaml
+func Nested(rel string, packDirs map[string]bool) bool {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if packDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// SortFiles sorts files by path
+func SortFiles(files []File) {
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+}
+
+// Digest hashes a pack's file set as "sha256:<hex>". It covers every
+// file's path, mode and content, so it changes when any file does. Lockfiles,
+// signatures and install metadata all record it.
+func Digest(files []File) string {
+	sorted := append([]File(nil), files...)
+	SortFiles(sorted)
+
+	h := sha256.New()
+	for _, f := range sorted {
+		sum := sha256.Sum256(f.Content)
+		fmt.Fprintf(h, "%s %o %s\n", hex.EncodeToString(sum[:]), NormalizeMode(f.Mode), f.Path)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks every file against its declared SHA-256 and path rules
+func Verify(files []File) error {
+	for _, f := range files {
+		if !ValidPath(f.Path) {
+			return fmt.Errorf("%w: %q", ErrUnsafePath, f.Path)
+		}
+		if f.SHA256 == "" {
+			continue
+		}
+		sum := sha256.Sum256(f.Content)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, f.SHA256) {
+			return fmt.Errorf("%w: %s\n  expected: %s\n  got:      %s", ErrFileHash, f.Path, f.SHA256, got)
+		}
+	}
+	return nil
+}
+
+// MainFile returns the content file of a file set: the one named by the
+// pack type, or the first content file present
+func MainFile(files []File, packType string) *File {
+	candidates := append([]string{ContentFile(packType)}, ContentFiles...)
+	for _, name := range candidates {
+		for i := range files {
+			if files[i].Path == name {
+				return &files[i]
+			}
+		}
+	}
+	return nil
+}
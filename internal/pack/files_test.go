@@ -0,0 +1,50 @@
+package pack
+
+import "testing"
+
+func TestValidName(t *testing.T) {
+	tests := map[string]bool{
+		"commit-message": true,
+		"react_patterns": true,
+		"v1.2":           true,
+		"":               false,
+		".":              false,
+		"..":             false,
+		".hidden":        false,
+		"a/b":            false,
+		"../../escape":   false,
+		`a\b`:            false,
+		"c:evil":         false,
+		"two words":      false,
+		"tab\tname":      false,
+		"new\nline":      false,
+	}
+	for name, want := range tests {
+		if got := ValidName(name); got != want {
+			t.Errorf("ValidName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestValidPath(t *testing.T) {
+	tests := map[string]bool{
+		"SKILL.md":        true,
+		"references/a.md": true,
+		"":                false,
+		".":               false,
+		"..":              false,
+		"../x":            false,
+		"a/../../x":       false,
+		"/etc/passwd":     false,
+		`ref\a.md`:        false,
+		"a//b":            false,
+		"a/./b":           false,
+		"references/":     false,
+		"references/../a": false,
+	}
+	for p, want := range tests {
+		if got := ValidPath(p); got != want {
+			t.Errorf("ValidPath(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package pack reads pack directories: a pack.yaml plus its content file.
+package pack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetaFile is the metadata file every pack directory contains
+const MetaFile = "pack.yaml"
+
+// ContentFiles are the content files a pack can ship, in lookup order
+var ContentFiles = []string{"SKILL.md", "CONTEXT.md", "PROMPT.md"}
+
+// Meta is the contents of pack.yaml
+type Meta struct {
+	Name        string   `yaml:"name"`
+	Version     string   `yaml:"version"`
+	Type        string   `yaml:"type"` // skill, context, prompt
+	Description string   `yaml:"description"`
+	Author      string   `yaml:"author"`
+	License     string   `yaml:"license,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	Repository  string   `yaml:"repository,omitempty"`
+
+	// Globs and AlwaysApply map onto agents' rule frontmatter: the files a
+	// rule attaches to, and whether it applies to every request
+	Globs       Globs `yaml:"globs,omitempty"`
+	AlwaysApply *bool `yaml:"always_apply,omitempty"`
+}
+
+// Globs is a list of file patterns, written as a YAML list or as one
+// comma-separated string
+type Globs []string
+
+func (g *Globs) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*g = nil
+		for _, glob := range strings.Split(node.Value, ",") {
+			if glob = strings.TrimSpace(glob); glob != "" {
+				*g = append(*g, glob)
+			}
+		}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*g = list
+	return nil
+}
+
+// ParseMeta parses pack.yaml bytes
+func ParseMeta(data []byte) (*Meta, error) {
+	var m Meta
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", MetaFile, err)
+	}
+	return &m, nil
+}
+
+// LoadMeta reads pack.yaml from a pack directory
+func LoadMeta(dir string) (*Meta, error) {
+	data, err := os.ReadFile(filepath.Join(dir, MetaFile))
+	if err != nil {
+		return nil, err
+	}
+	return ParseMeta(data)
+}
+
+// ContentFile returns the content file name for a pack type
+func ContentFile(packType string) string {
+	switch packType {
+	case "context":
+		return "CONTEXT.md"
+	case "prompt":
+		return "PROMPT.md"
+	default:
+		return "SKILL.md"
+	}
+}
+
+// ReadContent reads the content file of a pack directory. The file named by
+// the pack type wins; otherwise the first content file present is used.
+func ReadContent(dir, packType string) (content string, file string, err error) {
+	candidates := append([]string{ContentFile(packType)}, ContentFiles...)
+	for _, name := range candidates {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(data), name, nil
+		}
+	}
+	return "", "", fmt.Errorf("no content file in %s\nTried: SKILL.md, CONTEXT.md, PROMPT.md", dir)
+}
+
+// Hash returns the content hash of pack content as "sha256:<hex>"
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,262 @@
+// Package packref parses the references commands take to a pack:
+//
+//	commit-message               a registry pack, at its latest version
+//	commit-message@^1.2          a registry pack within a version range
+//	gh:user/repo/path@v1.2       a GitHub repo directory at a tag, branch or commit
+//	@user/repo/path              shorthand for gh:user/repo/path
+//	git+https://host/repo.git//path#v1.2
+//	git+ssh://git@host/repo.git  any git repo, with an optional directory and ref
+//	./dir, file:./dir            a pack directory on disk
+//	./pack.tgz, https://…/x.zip  a .tar.gz, .tgz or .zip archive file or URL
+//
+// Every reference has one canonical form, which String returns and Parse
+// reads back to the same PackRef.
+package packref
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/tunajam/packs/internal/archive"
+)
+
+// Kind is where a reference points
+type Kind string
+
+// Kinds, named as lockfiles record a pack's source
+const (
+	Registry Kind = "registry"
+	GitHub   Kind = "github"
+	Git      Kind = "git"
+	Local    Kind = "local"
+	Archive  Kind = "archive"
+)
+
+// PackRef is a parsed pack reference
+type PackRef struct {
+	Kind    Kind
+	Name    string // registry pack name
+	Version string // registry version or range; empty is the latest
+	Repo    string // GitHub user/repo, or the git URL without git+
+	Path    string // directory in the repo, or the local path or archive URL
+	Rev     string // GitHub or git tag, branch or commit; empty is the default branch
+}
+
+// Parse reads a pack reference
+func Parse(ref string) (PackRef, error) {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case ref == "":
+		return PackRef{}, fmt.Errorf("empty pack reference")
+	case strings.HasPrefix(ref, "gh:"):
+		return parseGitHub(ref, ref[len("gh:"):])
+	case strings.HasPrefix(ref, "@"):
+		return parseGitHub(ref, ref[1:])
+	case strings.HasPrefix(ref, "git+"):
+		return parseGit(ref)
+	case isURL(ref):
+		return PackRef{Kind: Archive, Path: ref}, nil
+	case strings.HasPrefix(ref, "file:"):
+		p := strings.TrimPrefix(ref[len("file:"):], "//")
+		if p == "" {
+			return PackRef{}, fmt.Errorf("invalid pack reference: %s\nExpected a path, like file:./my-pack", ref)
+		}
+		return local(p), nil
+	case isPath(ref) || archive.IsArchiveName(ref):
+		return local(ref), nil
+	}
+
+	name, version, _ := strings.Cut(ref, "@")
+	if name == "" || strings.ContainsAny(name, "/:\\ ") {
+		hint := "Use name[@version], gh:user/repo/path[@ref], git+https://host/repo.git, ./dir or an archive URL"
+		if strings.Contains(name, "/") && !strings.Contains(name, ":") {
+			hint = "For a GitHub repo use gh:" + ref
+		}
+		return PackRef{}, fmt.Errorf("invalid pack reference: %s\n%s", ref, hint)
+	}
+	return PackRef{Kind: Registry, Name: name, Version: version}, nil
+}
+
+// ParseSpec reads a packs.yaml entry for the pack called name: a
+// reference, or a version range of the registry pack with that name
+func ParseSpec(name, spec string) PackRef {
+	spec = strings.TrimSpace(spec)
+	if r, err := Parse(spec); err == nil && r.Kind != Registry {
+		return r
+	}
+	return PackRef{Kind: Registry, Name: name, Version: spec}
+}
+
+// parseGitHub reads user/repo[/path][@rev]
+func parseGitHub(ref, rest string) (PackRef, error) {
+	r := PackRef{Kind: GitHub}
+	rest, r.Rev, _ = strings.Cut(rest, "@")
+	if err := checkRev(ref, r.Rev); err != nil {
+		return PackRef{}, err
+	}
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return PackRef{}, fmt.Errorf("invalid GitHub reference: %s\nExpected format: gh:user/repo or gh:user/repo/path, with @tag, @branch or @commit", ref)
+	}
+	r.Repo = parts[0] + "/" + parts[1]
+	if len(parts) > 2 {
+		p, err := repoPath(ref, parts[2])
+		if err != nil {
+			return PackRef{}, err
+		}
+		r.Path = p
+	}
+	return r, nil
+}
+
+// parseGit reads git+<url>[//path][#rev]
+func parseGit(ref string) (PackRef, error) {
+	r := PackRef{Kind: Git}
+	rest, rev, _ := strings.Cut(ref[len("git+"):], "#")
+	if err := checkRev(ref, rev); err != nil {
+		return PackRef{}, err
+	}
+	r.Rev = rev
+
+	scheme, after, ok := strings.Cut(rest, "://")
+	switch {
+	case !ok:
+		return PackRef{}, fmt.Errorf("invalid git reference: %s\nExpected format: git+https://host/repo.git or git+ssh://git@host/repo.git", ref)
+	case scheme != "https" && scheme != "http" && scheme != "ssh" && scheme != "file":
+		return PackRef{}, fmt.Errorf("unsupported git URL scheme %q in %s\nUse git+https://, git+ssh:// or git+file://", scheme, ref)
+	}
+	// file:///repo has an empty host, so look for the path after its slash
+	skip := 0
+	if strings.HasPrefix(after, "/") {
+		skip = 1
+	}
+	if i := strings.Index(after[skip:], "//"); i != -1 {
+		p, err := repoPath(ref, after[skip+i+2:])
+		if err != nil {
+			return PackRef{}, err
+		}
+		r.Path = p
+		after = after[:skip+i]
+	}
+	if strings.Trim(after, "/") == "" {
+		return PackRef{}, fmt.Errorf("invalid git reference: %s\nExpected format: git+https://host/repo.git", ref)
+	}
+	r.Repo = scheme + "://" + after
+	return r, nil
+}
+
+// revChars matches the characters a branch, tag or commit can use here
+var revChars = regexp.MustCompile(`^[A-Za-z0-9._/+-]+$`)
+
+// checkRev rejects revs that aren't a plain branch, tag or commit name.
+// Revs end up on git's command line, so one that starts with - could pass
+// it an option.
+func checkRev(ref, rev string) error {
+	if rev == "" {
+		return nil
+	}
+	if !revChars.MatchString(rev) || strings.HasPrefix(rev, "-") || strings.HasPrefix(rev, "/") ||
+		strings.HasSuffix(rev, "/") || strings.Contains(rev, "..") || strings.Contains(rev, "//") {
+		return fmt.Errorf("invalid ref %q in %s\nUse a branch, tag or commit name", rev, ref)
+	}
+	return nil
+}
+
+// repoPath cleans a directory in a repo, which has to stay inside it
+func repoPath(ref, p string) (string, error) {
+	p = path.Clean(strings.Trim(p, "/"))
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("invalid pack reference: %s\nThe path has to stay inside the repo", ref)
+	}
+	if p == "." {
+		return "", nil
+	}
+	return p, nil
+}
+
+// local is a pack directory or archive file on disk
+func local(p string) PackRef {
+	kind := Local
+	if archive.IsArchiveName(p) {
+		kind = Archive
+	}
+	return PackRef{Kind: kind, Path: path.Clean(p)}
+}
+
+// String returns the canonical form of the reference
+func (r PackRef) String() string {
+	switch r.Kind {
+	case GitHub:
+		s := "gh:" + r.Repo
+		if r.Path != "" {
+			s += "/" + r.Path
+		}
+		if r.Rev != "" {
+			s += "@" + r.Rev
+		}
+		return s
+	case Git:
+		s := "git+" + r.Repo
+		if r.Path != "" {
+			s += "//" + r.Path
+		}
+		if r.Rev != "" {
+			s += "#" + r.Rev
+		}
+		return s
+	case Local, Archive:
+		if isURL(r.Path) || isPath(r.Path) {
+			return r.Path
+		}
+		return "./" + r.Path
+	}
+	if r.Version != "" {
+		return r.Name + "@" + r.Version
+	}
+	return r.Name
+}
+
+// BaseName is the name a pack gets from its reference, when it doesn't
+// name itself: the last directory of its path or repo, or the archive's
+// file name without its extension
+func (r PackRef) BaseName() string {
+	switch r.Kind {
+	case Registry:
+		return r.Name
+	case GitHub, Git:
+		if r.Path != "" {
+			return path.Base(r.Path)
+		}
+		return strings.TrimSuffix(path.Base(strings.TrimRight(r.Repo, "/")), ".git")
+	}
+
+	p := r.Path
+	if i := strings.IndexAny(p, "?#"); i != -1 && isURL(p) {
+		p = p[:i]
+	}
+	name := path.Base(strings.TrimRight(p, "/"))
+	for _, ext := range archiveExts {
+		if trimmed, ok := strings.CutSuffix(strings.ToLower(name), ext); ok {
+			return name[:len(trimmed)]
+		}
+	}
+	return name
+}
+
+// IsURL reports whether the reference is fetched over the network
+func (r PackRef) IsURL() bool {
+	return r.Kind == Archive && isURL(r.Path)
+}
+
+func isURL(ref string) bool {
+	return strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://")
+}
+
+// isPath reports whether a reference is a relative or absolute path
+func isPath(ref string) bool {
+	return ref == "." || ref == ".." || strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") || strings.HasPrefix(ref, "/")
+}
+
+var archiveExts = []string{".tar.gz", ".tgz", ".zip"}
@@ -0,0 +1,137 @@
+package packref
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		ref       string
+		want      PackRef
+		canonical string
+	}{
+		{"commit-message", PackRef{Kind: Registry, Name: "commit-message"}, "commit-message"},
+		{"commit-message@^1.2", PackRef{Kind: Registry, Name: "commit-message", Version: "^1.2"}, "commit-message@^1.2"},
+		{"gh:user/repo", PackRef{Kind: GitHub, Repo: "user/repo"}, "gh:user/repo"},
+		{"gh:user/repo/skills/hello@v1.2", PackRef{Kind: GitHub, Repo: "user/repo", Path: "skills/hello", Rev: "v1.2"}, "gh:user/repo/skills/hello@v1.2"},
+		{"@user/repo/hello", PackRef{Kind: GitHub, Repo: "user/repo", Path: "hello"}, "gh:user/repo/hello"},
+		{"gh:user/repo/a/../b/", PackRef{Kind: GitHub, Repo: "user/repo", Path: "b"}, "gh:user/repo/b"},
+		{"git+https://host/repo.git", PackRef{Kind: Git, Repo: "https://host/repo.git"}, "git+https://host/repo.git"},
+		{"git+https://host/repo.git//packs/hello#feature/x", PackRef{Kind: Git, Repo: "https://host/repo.git", Path: "packs/hello", Rev: "feature/x"}, "git+https://host/repo.git//packs/hello#feature/x"},
+		{"git+ssh://git@host/repo.git#v1.0.0", PackRef{Kind: Git, Repo: "ssh://git@host/repo.git", Rev: "v1.0.0"}, "git+ssh://git@host/repo.git#v1.0.0"},
+		{"git+file:///srv/repo//hello", PackRef{Kind: Git, Repo: "file:///srv/repo", Path: "hello"}, "git+file:///srv/repo//hello"},
+		{"./my-pack", PackRef{Kind: Local, Path: "my-pack"}, "./my-pack"},
+		{"../packs/hello/", PackRef{Kind: Local, Path: "../packs/hello"}, "../packs/hello"},
+		{"/abs/hello", PackRef{Kind: Local, Path: "/abs/hello"}, "/abs/hello"},
+		{"file:./dir", PackRef{Kind: Local, Path: "dir"}, "./dir"},
+		{"hello.tgz", PackRef{Kind: Archive, Path: "hello.tgz"}, "./hello.tgz"},
+		{"https://example.com/x.zip", PackRef{Kind: Archive, Path: "https://example.com/x.zip"}, "https://example.com/x.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := Parse(tt.ref)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.ref, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Parse(%q) = %+v, want %+v", tt.ref, got, tt.want)
+			}
+			if s := got.String(); s != tt.canonical {
+				t.Fatalf("String() = %q, want %q", s, tt.canonical)
+			}
+			again, err := Parse(got.String())
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", got.String(), err)
+			}
+			if again != got {
+				t.Fatalf("round trip of %q gave %+v, want %+v", tt.ref, again, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"", "empty pack reference"},
+		{"user/repo", "For a GitHub repo use gh:user/repo"},
+		{"gh:user", "invalid GitHub reference"},
+		{"gh:user/repo/../../etc", "has to stay inside the repo"},
+		{"gh:user/repo@--upload-pack=evil", "invalid ref"},
+		{"gh:user/repo@-b", "invalid ref"},
+		{"gh:user/repo@a..b", "invalid ref"},
+		{"gh:user/repo@v1 x", "invalid ref"},
+		{"gh:user/repo@/main", "invalid ref"},
+		{"gh:user/repo@main/", "invalid ref"},
+		{"gh:user/repo@a//b", "invalid ref"},
+		{"git+https://host/repo.git#--output=/tmp/x", "invalid ref"},
+		{"git+https://host/repo.git#$(id)", "invalid ref"},
+		{"git+ftp://host/repo.git", "unsupported git URL scheme"},
+		{"git+host/repo.git", "invalid git reference"},
+		{"git+https://host/repo.git//../x", "has to stay inside the repo"},
+		{"file:", "Expected a path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			_, err := Parse(tt.ref)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want an error", tt.ref)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse(%q) = %v, want it to mention %q", tt.ref, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		name, spec string
+		want       PackRef
+	}{
+		{"hello", "^1.2", PackRef{Kind: Registry, Name: "hello", Version: "^1.2"}},
+		{"hello", "latest", PackRef{Kind: Registry, Name: "hello", Version: "latest"}},
+		{"hello", "gh:user/repo/hello@main", PackRef{Kind: GitHub, Repo: "user/repo", Path: "hello", Rev: "main"}},
+		{"hello", "./vendor/hello", PackRef{Kind: Local, Path: "vendor/hello"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			if got := ParseSpec(tt.name, tt.spec); got != tt.want {
+				t.Fatalf("ParseSpec(%q, %q) = %+v, want %+v", tt.name, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseName(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"hello@1.0.0", "hello"},
+		{"gh:user/repo", "repo"},
+		{"gh:user/repo/skills/hello", "hello"},
+		{"git+https://host/repo.git", "repo"},
+		{"./packs/hello", "hello"},
+		{"./hello.tar.gz", "hello"},
+		{"https://example.com/dl/Hello.ZIP?token=x", "Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			r, err := Parse(tt.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.BaseName(); got != tt.want {
+				t.Fatalf("BaseName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package signing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy decides what happens to packs without a trusted signature
+type Policy string
+
+const (
+	// PolicyOff skips signature checks entirely
+	PolicyOff Policy = "off"
+	// PolicyVerify checks signatures from trusted keys and rejects bad ones;
+	// unsigned packs are installed
+	PolicyVerify Policy = "verify"
+	// PolicyRequire also refuses packs without a trusted signature, unless
+	// their source is allowlisted
+	PolicyRequire Policy = "require"
+)
+
+// TrustedKey is a publisher key the user trusts, optionally only for one
+// author or one registry
+type TrustedKey struct {
+	Key      string `yaml:"key"`
+	Author   string `yaml:"author,omitempty"`
+	Registry string `yaml:"registry,omitempty"`
+	Comment  string `yaml:"comment,omitempty"`
+}
+
+// ID returns the key ID, or "" if the key doesn't parse
+func (k TrustedKey) ID() string {
+	pub, err := ParsePublicKey(k.Key)
+	if err != nil {
+		return ""
+	}
+	return KeyID(pub)
+}
+
+// Subject is a fetched pack to check
+type Subject struct {
+	Name        string
+	Version     string
+	ContentHash string
+	Author      string
+	Registry    string // registry that served it, "" for GitHub
+	Source      string // registry name or gh:user/repo/path, for the allowlist
+	Signature   string
+	KeyID       string
+	Incomplete  bool // only the main content was served, not every file
+}
+
+// Result describes a checked pack
+type Result struct {
+	Signed  bool   // the pack carries a signature
+	Trusted bool   // the signature verified against a trusted key
+	KeyID   string // key that signed it
+}
+
+// Verifier applies the trusted keys and policy
+type Verifier struct {
+	Policy        Policy
+	Keys          []TrustedKey
+	AllowUnsigned []string // sources exempt from PolicyRequire
+}
+
+// Check verifies a pack's signature. A signature from a trusted key that
+// doesn't verify, or can't since the pack is incomplete, is always an
+// error; under PolicyRequire so is a pack without a trusted signature from
+// a source that isn't allowlisted.
+func (v *Verifier) Check(s Subject) (Result, error) {
+	res := Result{Signed: s.Signature != "", KeyID: s.KeyID}
+	if v.Policy == PolicyOff {
+		return res, nil
+	}
+
+	if res.Signed {
+		if key, ok := v.trustedKey(s); ok {
+			pub, err := ParsePublicKey(key.Key)
+			if err != nil {
+				return res, err
+			}
+			if s.Incomplete {
+				return res, fmt.Errorf("%w: %s@%s came with its main content only", ErrIncomplete, s.Name, s.Version)
+			}
+			if err := Verify(pub, Payload(s.Name, s.Version, s.ContentHash), s.Signature); err != nil {
+				return res, fmt.Errorf("%w on %s@%s from key %s", ErrBadSignature, s.Name, s.Version, s.KeyID)
+			}
+			res.Trusted = true
+			return res, nil
+		}
+	}
+
+	if v.Policy == PolicyRequire && !v.allowed(s.Source) {
+		what := "unsigned"
+		if res.Signed {
+			what = "signed by untrusted key " + s.KeyID
+		}
+		return res, fmt.Errorf("%w: %s@%s from %s is %s", ErrUntrusted, s.Name, s.Version, s.Source, what)
+	}
+	return res, nil
+}
+
+// trustedKey finds a trusted key with the subject's key ID whose scope
+// covers the subject
+func (v *Verifier) trustedKey(s Subject) (TrustedKey, bool) {
+	for _, k := range v.Keys {
+		if k.ID() != s.KeyID {
+			continue
+		}
+		if k.Author != "" && !strings.EqualFold(k.Author, s.Author) {
+			continue
+		}
+		if k.Registry != "" && k.Registry != s.Registry {
+			continue
+		}
+		return k, true
+	}
+	return TrustedKey{}, false
+}
+
+// allowed reports whether a source is allowlisted. Entries ending in "*"
+// match by prefix, e.g. "gh:acme/*".
+func (v *Verifier) allowed(source string) bool {
+	for _, a := range v.AllowUnsigned {
+		if prefix, ok := strings.CutSuffix(a, "*"); ok {
+			if strings.HasPrefix(source, prefix) {
+				return true
+			}
+		} else if a == source {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, otherPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := TrustedKey{Key: EncodePublicKey(pub)}
+
+	signed := func(priv ed25519.PrivateKey, pub ed25519.PublicKey) Subject {
+		s := Subject{Name: "hello", Version: "1.0.0", ContentHash: "sha256:abc", Author: "tunajam", Registry: "packs.sh", Source: "packs.sh"}
+		s.Signature = Sign(priv, Payload(s.Name, s.Version, s.ContentHash))
+		s.KeyID = KeyID(pub)
+		return s
+	}
+	good := signed(priv, pub)
+	untrusted := signed(otherPriv, otherPub)
+	unsigned := Subject{Name: "hello", Version: "1.0.0", ContentHash: "sha256:abc", Source: "packs.sh"}
+	tampered := good
+	tampered.ContentHash = "sha256:abd"
+	incomplete := good
+	incomplete.Incomplete = true
+	incompleteUntrusted := untrusted
+	incompleteUntrusted.Incomplete = true
+
+	tests := []struct {
+		name        string
+		verifier    Verifier
+		subject     Subject
+		wantErr     error
+		wantTrusted bool
+	}{
+		{"verify trusted", Verifier{Policy: PolicyVerify, Keys: []TrustedKey{key}}, good, nil, true},
+		{"verify untrusted", Verifier{Policy: PolicyVerify, Keys: []TrustedKey{key}}, untrusted, nil, false},
+		{"verify unsigned", Verifier{Policy: PolicyVerify, Keys: []TrustedKey{key}}, unsigned, nil, false},
+		{"verify tampered", Verifier{Policy: PolicyVerify, Keys: []TrustedKey{key}}, tampered, ErrBadSignature, false},
+		{"verify incomplete", Verifier{Policy: PolicyVerify, Keys: []TrustedKey{key}}, incomplete, ErrIncomplete, false},
+		{"verify incomplete untrusted", Verifier{Policy: PolicyVerify, Keys: []TrustedKey{key}}, incompleteUntrusted, nil, false},
+		{"off tampered", Verifier{Policy: PolicyOff, Keys: []TrustedKey{key}}, tampered, nil, false},
+		{"require trusted", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{key}}, good, nil, true},
+		{"require untrusted", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{key}}, untrusted, ErrUntrusted, false},
+		{"require unsigned", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{key}}, unsigned, ErrUntrusted, false},
+		{"require incomplete", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{key}}, incomplete, ErrIncomplete, false},
+		{"require tampered allowlisted", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{key}, AllowUnsigned: []string{"packs.sh"}}, tampered, ErrBadSignature, false},
+		{"require unsigned allowlisted", Verifier{Policy: PolicyRequire, AllowUnsigned: []string{"packs.sh"}}, unsigned, nil, false},
+		{"require unsigned allowlisted by prefix", Verifier{Policy: PolicyRequire, AllowUnsigned: []string{"packs.*"}}, unsigned, nil, false},
+		{"require unsigned other allowlisted", Verifier{Policy: PolicyRequire, AllowUnsigned: []string{"packs.acme.corp"}}, unsigned, ErrUntrusted, false},
+		{"key for author", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{{Key: key.Key, Author: "TunaJam"}}}, good, nil, true},
+		{"key for other author", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{{Key: key.Key, Author: "someone"}}}, good, ErrUntrusted, false},
+		{"key for registry", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{{Key: key.Key, Registry: "packs.sh"}}}, good, nil, true},
+		{"key for other registry", Verifier{Policy: PolicyRequire, Keys: []TrustedKey{{Key: key.Key, Registry: "packs.acme.corp"}}}, good, ErrUntrusted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.verifier.Check(tt.subject)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Check = %v, want %v", err, tt.wantErr)
+			}
+			if res.Trusted != tt.wantTrusted {
+				t.Errorf("Trusted = %v, want %v", res.Trusted, tt.wantTrusted)
+			}
+			if res.Signed != (tt.subject.Signature != "") {
+				t.Errorf("Signed = %v for signature %q", res.Signed, tt.subject.Signature)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Package signing signs packs and verifies their signatures with ed25519.
+//
+// A signature covers the pack's identity and content:
+//
+//	packs-signature-v1
+//	name: <name>
+//	version: <version>
+//	content_hash: sha256:<hex>
+//
+// so a registry can't serve another pack's content, or another version,
+// under a valid signature. The content hash is the digest of every file in
+// the pack, pack.yaml included, so a registry has to serve a signed pack's
+// files, not only its main content, for the signature to verify. Authors
+// ship it as pack.sig next to pack.yaml and registries serve it in the Pack
+// signature and key_id fields.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SigFile is the signature file authors commit next to pack.yaml
+const SigFile = "pack.sig"
+
+const (
+	publicPrefix  = "ed25519:"
+	privatePrefix = "ed25519-private:"
+)
+
+var (
+	// ErrBadSignature is returned when a signature doesn't verify
+	ErrBadSignature = errors.New("invalid signature")
+
+	// ErrUntrusted is returned when the policy refuses a pack that isn't
+	// signed by a trusted key
+	ErrUntrusted = errors.New("not signed by a trusted key")
+
+	// ErrIncomplete is returned when a pack signed by a trusted key comes
+	// without the files its signature covers
+	ErrIncomplete = errors.New("signed files not served")
+)
+
+// Signature is the contents of pack.sig
+type Signature struct {
+	KeyID     string `yaml:"key_id"`
+	Signature string `yaml:"signature"`
+}
+
+// ParseSignature parses pack.sig bytes
+func ParseSignature(data []byte) (*Signature, error) {
+	var s Signature
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SigFile, err)
+	}
+	if s.KeyID == "" || s.Signature == "" {
+		return nil, fmt.Errorf("invalid %s: key_id and signature are required", SigFile)
+	}
+	return &s, nil
+}
+
+// Marshal encodes a signature as pack.sig
+func (s *Signature) Marshal() []byte {
+	return []byte(fmt.Sprintf("key_id: %s\nsignature: %s\n", s.KeyID, s.Signature))
+}
+
+// Payload is the message a pack signature covers
+func Payload(name, version, contentHash string) []byte {
+	return []byte(fmt.Sprintf("packs-signature-v1\nname: %s\nversion: %s\ncontent_hash: %s\n",
+		name, version, strings.ToLower(contentHash)))
+}
+
+// KeyID derives a short, stable ID from a public key
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign signs a payload and returns the base64 signature
+func Sign(priv ed25519.PrivateKey, payload []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload))
+}
+
+// Verify checks a base64 signature over a payload
+func Verify(pub ed25519.PublicKey, payload []byte, sig string) error {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig))
+	if err != nil || !ed25519.Verify(pub, payload, raw) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// EncodePublicKey encodes a public key as "ed25519:<base64>"
+func EncodePublicKey(pub ed25519.PublicKey) string {
+	return publicPrefix + base64.StdEncoding.EncodeToString(pub)
+}
+
+// ParsePublicKey parses an encoded public key. The prefix is optional.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), publicPrefix))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: expected %s<base64>", publicPrefix)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// GenerateKey creates a key pair and writes the private key to path (0600)
+// and the public key to path + ".pub". Existing keys are never overwritten.
+func GenerateKey(path string) (ed25519.PublicKey, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("key already exists: %s", path)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	seed := privatePrefix + base64.StdEncoding.EncodeToString(priv.Seed()) + "\n"
+	if err := os.WriteFile(path, []byte(seed), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path+".pub", []byte(EncodePublicKey(pub)+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// LoadPrivateKey reads a private key written by GenerateKey
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), privatePrefix))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key: %s", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,102 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := Payload("hello", "1.0.0", "sha256:ABC")
+	sig := Sign(priv, payload)
+
+	if err := Verify(pub, payload, sig); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := Verify(pub, payload, " "+sig+"\n"); err != nil {
+		t.Errorf("Verify with surrounding space: %v", err)
+	}
+
+	bad := []struct {
+		name    string
+		pub     ed25519.PublicKey
+		payload []byte
+		sig     string
+	}{
+		{"other key", otherPub, payload, sig},
+		{"other name", pub, Payload("hullo", "1.0.0", "sha256:abc"), sig},
+		{"other version", pub, Payload("hello", "1.0.1", "sha256:abc"), sig},
+		{"other content", pub, Payload("hello", "1.0.0", "sha256:abd"), sig},
+		{"not base64", pub, payload, "not base64!"},
+		{"empty", pub, payload, ""},
+	}
+	for _, tt := range bad {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.pub, tt.payload, tt.sig); !errors.Is(err, ErrBadSignature) {
+				t.Errorf("Verify = %v, want ErrBadSignature", err)
+			}
+		})
+	}
+}
+
+func TestPayloadLowercasesHash(t *testing.T) {
+	if a, b := Payload("x", "1", "sha256:ABC"), Payload("x", "1", "sha256:abc"); string(a) != string(b) {
+		t.Errorf("payloads differ by hash case:\n%s\n%s", a, b)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "signing.key")
+	pub, err := GenerateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateKey(path); err == nil {
+		t.Error("GenerateKey overwrote an existing key")
+	}
+
+	priv, err := LoadPrivateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(priv.Public()) {
+		t.Error("loaded private key doesn't match the generated public key")
+	}
+
+	parsed, err := ParsePublicKey(EncodePublicKey(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(pub) || KeyID(parsed) != KeyID(pub) {
+		t.Error("public key didn't round trip")
+	}
+	if _, err := ParsePublicKey("ed25519:AAAA"); err == nil {
+		t.Error("ParsePublicKey accepted a short key")
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	s := &Signature{KeyID: "0123456789abcdef", Signature: "c2ln"}
+	got, err := ParseSignature(s.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *s {
+		t.Errorf("ParseSignature = %+v, want %+v", got, s)
+	}
+
+	for _, data := range []string{"", "key_id: abc\n", "signature: c2ln\n", "[not: yaml"} {
+		if _, err := ParseSignature([]byte(data)); err == nil {
+			t.Errorf("ParseSignature(%q) succeeded", data)
+		}
+	}
+}
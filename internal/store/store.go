@@ -0,0 +1,307 @@
+// Package store is the content-addressed store packs links installs to,
+// under ~/.packs/store. Installing the same pack for several agents puts
+// its files in the store once, and each agent directory gets links to them
+// instead of its own copy.
+//
+// Layout:
+//
+//	<digest>/<path>      a pack's files, as laid out for an agent
+//	refs/<digest>.json   the agent directories that link to an entry
+//
+// An entry is keyed by the digest of its file set, so agents that lay a
+// pack out the same way share one entry. Entry files are read-only, since
+// a hardlinked install shares them: editors then save an edited copy as a
+// new file instead of changing the store and every other install with it.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tunajam/packs/internal/atomicfile"
+	"github.com/tunajam/packs/internal/pack"
+)
+
+// Link is how an installed file points at the store
+type Link string
+
+// Links
+const (
+	Hardlink Link = "hardlink"
+	Symlink  Link = "symlink"
+	Copy     Link = "copy"
+)
+
+// Store is a directory of content-addressed file sets
+type Store struct {
+	dir string
+}
+
+// Ref is a pack installed from a store entry
+type Ref struct {
+	Root string `json:"root"` // agent directory, absolute
+	Name string `json:"name"`
+}
+
+// Entry is one file set in the store
+type Entry struct {
+	Hash string
+	Refs []Ref
+	Size int64
+}
+
+// New opens the store in dir
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the store directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key returns the entry a file set is stored under
+func Key(files []pack.File) string {
+	return strings.TrimPrefix(pack.Digest(files), "sha256:")
+}
+
+// Path returns where a file of an entry lives
+func (s *Store) Path(hash, rel string) string {
+	return filepath.Join(s.dir, hash, filepath.FromSlash(rel))
+}
+
+// Put adds a file set to the store and returns its key. Files already
+// there are kept unless they were changed, in which case they're replaced;
+// links to the changed file keep the change.
+func (s *Store) Put(files []pack.File) (string, error) {
+	hash := Key(files)
+	for _, f := range files {
+		if !pack.ValidPath(f.Path) {
+			return "", fmt.Errorf("%w: %q", pack.ErrUnsafePath, f.Path)
+		}
+		path := s.Path(hash, f.Path)
+		if same(path, f) {
+			continue
+		}
+		if err := atomicfile.Write(path, f.Content, readOnly(f.Mode)); err != nil {
+			return "", err
+		}
+	}
+	return hash, nil
+}
+
+// readOnly is the mode an entry file with mode m gets
+func readOnly(m uint32) os.FileMode {
+	return os.FileMode(pack.NormalizeMode(m)) &^ 0222
+}
+
+// same reports whether path holds exactly f, read-only
+func same(path string, f pack.File) bool {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Mode().Perm() != readOnly(f.Mode) {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && string(data) == string(f.Content)
+}
+
+// Link puts a file of an entry at dst, replacing what's there. It tries
+// each link in turn and copies the file when none works, since hardlinks
+// fail across filesystems and symlinks where they aren't allowed. It
+// returns the link used. Linked files are read-only like the entry; a
+// copy is writable.
+func (s *Store) Link(hash string, f pack.File, dst string, links ...Link) (Link, error) {
+	src := s.Path(hash, f.Path)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", err
+	}
+
+	tmp := dst + ".tmp"
+	for _, link := range links {
+		os.Remove(tmp)
+		var err error
+		switch link {
+		case Hardlink:
+			err = os.Link(src, tmp)
+		case Symlink:
+			err = os.Symlink(src, tmp)
+		default:
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+		return link, nil
+	}
+	return Copy, atomicfile.Write(dst, f.Content, os.FileMode(pack.NormalizeMode(f.Mode)))
+}
+
+// Verify re-hashes an entry's files against its key. An entry whose files
+// were changed in place, through a link or otherwise, fails; so does one
+// that's missing files or has extra ones.
+func (s *Store) Verify(hash string) error {
+	root := filepath.Join(s.dir, hash)
+	var files []pack.File
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("not a regular file: %s", p)
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		files = append(files, pack.File{Path: filepath.ToSlash(rel), Mode: uint32(info.Mode().Perm()), Content: content})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if got := Key(files); got != hash {
+		return fmt.Errorf("content changed: hashes to %s", got[:12])
+	}
+	return nil
+}
+
+// AddRef records that a pack in an agent directory links to an entry
+func (s *Store) AddRef(hash string, ref Ref) error {
+	refs, err := s.refs(hash)
+	if err != nil {
+		return err
+	}
+	for _, r := range refs {
+		if r == ref {
+			return nil
+		}
+	}
+	return s.writeRefs(hash, append(refs, ref))
+}
+
+// RemoveRef drops a pack's reference to an entry. The entry stays until
+// the next GC.
+func (s *Store) RemoveRef(hash string, ref Ref) error {
+	refs, err := s.refs(hash)
+	if err != nil {
+		return err
+	}
+	kept := refs[:0]
+	for _, r := range refs {
+		if r != ref {
+			kept = append(kept, r)
+		}
+	}
+	return s.writeRefs(hash, kept)
+}
+
+// SetRefs replaces an entry's references
+func (s *Store) SetRefs(hash string, refs []Ref) error {
+	return s.writeRefs(hash, refs)
+}
+
+// Entries lists the entries in the store with their references and sizes
+func (s *Store) Entries() ([]*Entry, error) {
+	dirs, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, d := range dirs {
+		if !d.IsDir() || d.Name() == "refs" {
+			continue
+		}
+		refs, err := s.refs(d.Name())
+		if err != nil {
+			return nil, err
+		}
+		e := &Entry{Hash: d.Name(), Refs: refs}
+		filepath.WalkDir(filepath.Join(s.dir, d.Name()), func(_ string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				if info, err := d.Info(); err == nil {
+					e.Size += info.Size()
+				}
+			}
+			return nil
+		})
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Remove deletes an entry. Hardlinked installs keep their files; symlinked
+// ones break.
+func (s *Store) Remove(hash string) error {
+	if err := os.RemoveAll(filepath.Join(s.dir, hash)); err != nil {
+		return err
+	}
+	err := os.Remove(s.refsPath(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) refsPath(hash string) string {
+	return filepath.Join(s.dir, "refs", hash+".json")
+}
+
+// Refs lists the packs linked to an entry
+func (s *Store) Refs(hash string) ([]Ref, error) {
+	return s.refs(hash)
+}
+
+func (s *Store) refs(hash string) ([]Ref, error) {
+	data, err := os.ReadFile(s.refsPath(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var refs []Ref
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.refsPath(hash), err)
+	}
+	return refs, nil
+}
+
+func (s *Store) writeRefs(hash string, refs []Ref) error {
+	if len(refs) == 0 {
+		err := os.Remove(s.refsPath(hash))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Root != refs[j].Root {
+			return refs[i].Root < refs[j].Root
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	data, err := json.MarshalIndent(refs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(s.refsPath(hash), append(data, '\n'), 0644)
+}
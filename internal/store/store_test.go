@@ -0,0 +1,228 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tunajam/packs/internal/pack"
+)
+
+func testFiles() []pack.File {
+	return []pack.File{
+		{Path: "SKILL.md", Mode: 0644, Content: []byte("# Hello\n")},
+		{Path: "scripts/run.sh", Mode: 0755, Content: []byte("#!/bin/sh\necho hi\n")},
+	}
+}
+
+func TestPut(t *testing.T) {
+	s := New(t.TempDir())
+	files := testFiles()
+
+	hash, err := s.Put(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != Key(files) {
+		t.Errorf("Put = %s, want Key %s", hash, Key(files))
+	}
+	for _, f := range files {
+		info, err := os.Stat(s.Path(hash, f.Path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := readOnly(f.Mode); info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, want %v", f.Path, info.Mode().Perm(), want)
+		}
+	}
+
+	// A changed file is replaced; an unchanged one is left alone
+	path := s.Path(hash, "SKILL.md")
+	os.Chmod(path, 0644)
+	os.WriteFile(path, []byte("edited\n"), 0644)
+	before, _ := os.Stat(s.Path(hash, "scripts/run.sh"))
+
+	if _, err := s.Put(files); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# Hello\n" {
+		t.Errorf("changed file not replaced: %q", data)
+	}
+	if after, _ := os.Stat(s.Path(hash, "scripts/run.sh")); !os.SameFile(before, after) {
+		t.Error("unchanged file was rewritten")
+	}
+	if err := s.Verify(hash); err != nil {
+		t.Errorf("Verify after repair: %v", err)
+	}
+}
+
+func TestPutRejectsUnsafePaths(t *testing.T) {
+	dir := t.TempDir()
+	s := New(filepath.Join(dir, "store"))
+	for _, p := range []string{"../escape", "/abs", "a/../../b"} {
+		_, err := s.Put([]pack.File{{Path: p, Content: []byte("x")}})
+		if !errors.Is(err, pack.ErrUnsafePath) {
+			t.Errorf("Put(%q) = %v, want ErrUnsafePath", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape")); err == nil {
+		t.Error("Put wrote outside the store")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *Store, hash string)
+	}{
+		{"changed content", func(s *Store, hash string) {
+			path := s.Path(hash, "SKILL.md")
+			os.Chmod(path, 0644)
+			os.WriteFile(path, []byte("edited\n"), 0644)
+		}},
+		{"changed mode", func(s *Store, hash string) {
+			os.Chmod(s.Path(hash, "scripts/run.sh"), 0444)
+		}},
+		{"missing file", func(s *Store, hash string) {
+			os.Remove(s.Path(hash, "SKILL.md"))
+		}},
+		{"extra file", func(s *Store, hash string) {
+			os.WriteFile(s.Path(hash, "extra.md"), []byte("x"), 0444)
+		}},
+		{"symlink", func(s *Store, hash string) {
+			path := s.Path(hash, "SKILL.md")
+			os.Remove(path)
+			os.Symlink(s.Path(hash, "scripts/run.sh"), path)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(t.TempDir())
+			hash, err := s.Put(testFiles())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Verify(hash); err != nil {
+				t.Fatalf("Verify before change: %v", err)
+			}
+			tt.change(s, hash)
+			if err := s.Verify(hash); err == nil {
+				t.Error("Verify passed")
+			}
+		})
+	}
+}
+
+func TestLink(t *testing.T) {
+	s := New(t.TempDir())
+	files := testFiles()
+	hash, err := s.Put(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := files[0]
+	src := s.Path(hash, f.Path)
+
+	tests := []struct {
+		name  string
+		hash  string
+		links []Link
+		want  Link
+	}{
+		{"hardlink", hash, []Link{Hardlink, Symlink}, Hardlink},
+		{"symlink", hash, []Link{Symlink}, Symlink},
+		{"copy", hash, []Link{Copy}, Copy},
+		{"no links", hash, nil, Copy},
+		// With nothing to link to, each link fails in turn
+		{"hardlink falls back to symlink", "missing", []Link{Hardlink, Symlink}, Symlink},
+		{"hardlink falls back to copy", "missing", []Link{Hardlink}, Copy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "agent", f.Path)
+			os.MkdirAll(filepath.Dir(dst), 0755)
+			os.WriteFile(dst, []byte("replaced\n"), 0644)
+
+			got, err := s.Link(tt.hash, f, dst, tt.links...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Link = %s, want %s", got, tt.want)
+			}
+			if _, err := os.Lstat(dst + ".tmp"); err == nil {
+				t.Error("temporary link left behind")
+			}
+
+			info, err := os.Lstat(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch got {
+			case Hardlink:
+				srcInfo, _ := os.Stat(src)
+				if !os.SameFile(info, srcInfo) {
+					t.Error("not a hardlink to the entry")
+				}
+			case Symlink:
+				if target, _ := os.Readlink(dst); target != s.Path(tt.hash, f.Path) {
+					t.Errorf("symlink to %s, want %s", target, s.Path(tt.hash, f.Path))
+				}
+			case Copy:
+				if info.Mode().Perm()&0200 == 0 {
+					t.Error("copy is read-only")
+				}
+				if data, _ := os.ReadFile(dst); string(data) != string(f.Content) {
+					t.Errorf("copy holds %q", data)
+				}
+			}
+		})
+	}
+}
+
+func TestRefs(t *testing.T) {
+	s := New(t.TempDir())
+	hash, err := s.Put(testFiles())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Ref{Root: "/project/.claude/skills", Name: "hello"}
+	b := Ref{Root: "/home/me/.claude/skills", Name: "hello"}
+
+	for _, ref := range []Ref{a, b, a} {
+		if err := s.AddRef(hash, ref); err != nil {
+			t.Fatal(err)
+		}
+	}
+	refs, err := s.Refs(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 || refs[0] != b || refs[1] != a {
+		t.Errorf("Refs = %v, want [%v %v]", refs, b, a)
+	}
+
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Hash != hash || len(entries[0].Refs) != 2 || entries[0].Size == 0 {
+		t.Errorf("Entries = %+v", entries)
+	}
+
+	s.RemoveRef(hash, a)
+	s.RemoveRef(hash, b)
+	if _, err := os.Stat(s.refsPath(hash)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("refs file kept with no refs")
+	}
+
+	if err := s.Remove(hash); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := s.Entries(); len(entries) != 0 {
+		t.Errorf("Entries after Remove = %+v", entries)
+	}
+}